@@ -0,0 +1,103 @@
+/*
+
+goutils-configcrypt creates keys and encrypts values for the config files read by
+goutils.ConfigReader (see config_ini_crypt.go):
+
+			goutils-configcrypt genkey > /etc/myapp/config.key
+			goutils-configcrypt encrypt -key-file /etc/myapp/config.key 's3cr3t'
+			echo -n 's3cr3t' | goutils-configcrypt encrypt -key-env MYAPP_CONFIG_KEY
+			goutils-configcrypt decrypt -key-file /etc/myapp/config.key 'enc:v1:...'
+
+the key is read from -key-file, else from the variable of -key-env (GOUTILS_CONFIG_KEY
+by default); the value is read from the standard input when not given
+
+*/
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/maxstrambini/goutils"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+	var err error
+	switch os.Args[1] {
+	case "genkey":
+		var key string
+		if key, err = goutils.GenerateConfigKey(); err == nil {
+			fmt.Println(key)
+		}
+	case "encrypt", "decrypt":
+		err = crypt(os.Args[1], os.Args[2:])
+	default:
+		usage()
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "goutils-configcrypt: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: goutils-configcrypt genkey\n"+
+		"       goutils-configcrypt encrypt|decrypt [-key-file path | -key-env name] [value]\n")
+	os.Exit(2)
+}
+
+// crypt encrypts or decrypts the value given as argument or on the standard input
+func crypt(command string, args []string) error {
+	fs := flag.NewFlagSet(command, flag.ExitOnError)
+	keyFile := fs.String("key-file", "", "file holding the key, as base64 or hex")
+	keyEnv := fs.String("key-env", goutils.DefaultDecryptionKeyEnv, "environment variable holding the key")
+	fs.Parse(args)
+
+	var keyText string
+	if *keyFile != "" {
+		data, err := os.ReadFile(*keyFile)
+		if err != nil {
+			return err
+		}
+		keyText = string(data)
+	} else if keyText = os.Getenv(*keyEnv); keyText == "" {
+		return fmt.Errorf("%v: set %s or use -key-file", goutils.ErrNoDecryptionKey, *keyEnv)
+	}
+	key, err := goutils.ParseConfigKey(keyText)
+	if err != nil {
+		return err
+	}
+
+	var value string
+	switch fs.NArg() {
+	case 0:
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return err
+		}
+		value = strings.TrimRight(string(data), "\r\n")
+	case 1:
+		value = fs.Arg(0)
+	default:
+		usage()
+	}
+
+	var result string
+	if command == "encrypt" {
+		result, err = goutils.EncryptConfigValue(key, value)
+	} else {
+		result, err = goutils.DecryptConfigValue(key, value)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Println(result)
+	return nil
+}
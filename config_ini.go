@@ -1,87 +1,529 @@
 package goutils
 
 import (
-	"bufio"
 	"fmt"
+	"io"
+	"io/fs"
 	"log"
 	"os"
+	"path"
+	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 )
 
 //ConfigReader struct representing a config file
+//Keys found after a '[section]' header are stored qualified as 'section.key',
+//keys found before any header keep their plain name.
+//A ConfigReader is safe for concurrent use, a reload is seen at once by the getters
 type ConfigReader struct {
 	configFilePath string
 	nItems         int
+	lock           sync.RWMutex // guards configFilePath and the fields below, replaced at once by Read and Watch
+	savePath       string       // file written by Save
 	items          map[string]string
+	sections       []string     // section names in file order
+	lines          []configLine // lines of the file, used to write it back
+	origins        map[string]itemOrigin
+	history        map[string][]itemOrigin // every value given to an item, lowest priority first
+	warnings       []*ParseError
+	files          []watchedFile
+	reload         func() (*parsedConfig, error) // reads again the same files, used by Watch
+	onChange       []func(old, new map[string]string)
+	onDiff         []func(diff *ConfigDiff)
+	onReloadError  []func(err error)
+	readOnly       bool             // true for snapshots
+	profile        string           // selected by WithProfile or SelectProfile
+	activeProfile  string           // profile of the items read
+	profiles       []string         // profiles found in the files
+	format         ConfigFileFormat // encoding and line ends of the file, used by Save
+	noLines        bool             // true after ReadDotEnv, there are no lines to write back
+
+	configOptions // set by NewConfigReader, never changed afterwards
+}
+
+//configOptions are the settings of a ConfigReader changed by a ConfigOption
+type configOptions struct {
+	envPrefix      string     // when not empty environment variables override file values
+	interpolation  bool       // when true ${...} references are expanded
+	parserMode     ParserMode // syntax of the files, ParserModeLegacy by default
+	strict         bool       // when true problems in the files are errors instead of warnings
+	maxLineLength  int        // 0 means defaultMaxLineLength
+	logger         ConfigLogger
+	secretPatterns []string          // nil means DefaultSecretPatterns
+	defaults       map[string]string // values used when no file sets them
+	defaultsINI    string            // as defaults, in the syntax of the files
+
+	decryptionKey     []byte // key of the enc:v1: values, or read from the file or variable below
+	decryptionKeyFile string
+	decryptionKeyEnv  string // empty means DefaultDecryptionKeyEnv
+
+	profileEnv       string   // variable selecting the profile, empty means DefaultProfileEnv
+	declaredProfiles []string // names given to WithProfiles
+
+	aliases       map[string][]keyAlias // deprecated names by new name
+	aliasWarnings *aliasWarnings
+
+	fallbackEncoding ConfigEncoding // encoding of the files not valid UTF-8, empty to read them as they are
+}
+
+//parsedConfig is the result of parsing config files, installed at once in a ConfigReader
+type parsedConfig struct {
+	path     string // file whose lines are kept, the last one read
+	savePath string // file written by Save, empty when not read from the file system
+	items    map[string]string
+	sections []string
+	lines    []configLine
+	origins  map[string]itemOrigin
+	history  map[string][]itemOrigin
+	files    []watchedFile
+	warnings []*ParseError    // problems found while parsing in lenient mode
+	profile  string           // profile applied to the items
+	profiles []string         // profiles found
+	format   ConfigFileFormat // encoding and line ends of the file whose lines are kept
+	noLines  bool             // read from .env files, whose lines are not kept
+}
+
+//itemOrigin is where an item was read from
+type itemOrigin struct {
+	source    ConfigSource
+	path      string
+	line      int
+	encrypted bool   // the value was decrypted while read
+	profile   string // profile that supplied the value, empty for a shared one
+	value     string // value as written, kept in the history
+}
+
+//configLine is a line of a config file as read, kept to write the file back
+type configLine struct {
+	text    string // the whole line
+	section string // section the line belongs to
+	key     string // key as written in the file, empty for comments, blank, header and invalid lines
+
+	valueStart, valueEnd int // position of the value in text, replaced by Set
+}
+
+//watchedFile is a file or folder read, with its stat when it was read (nil when missing)
+type watchedFile struct {
+	path string
+	info os.FileInfo
+	fsys fs.FS // nil for the os file system
+}
+
+//ConfigOption configures a ConfigReader created with NewConfigReader
+type ConfigOption func(c *ConfigReader)
+
+//NewConfigReader returns a ConfigReader configured with the given options
+//A zero ConfigReader is ready to use as well when no option is needed
+func NewConfigReader(opts ...ConfigOption) *ConfigReader {
+	c := &ConfigReader{}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
 }
 
 //Read and parse a configuration file
 func (c *ConfigReader) Read(configPath string) (numItemsFound int, err error) {
+	failed := &parsedConfig{path: configPath, savePath: configPath, files: []watchedFile{{path: configPath}}}
+	return c.load(failed, func() (*parsedConfig, error) {
+		return c.parseFiles([]string{configPath}, nil)
+	})
+}
+
+//load installs the result of reload, or failed with no items when it fails
+func (c *ConfigReader) load(failed *parsedConfig, reload func() (*parsedConfig, error)) (numItemsFound int, err error) {
+
+	if c.readOnly {
+		err = errReadOnly
+		c.logf("goutils.ConfigReader.Read(%s) failed: %s", failed.path, err)
+		return
+	}
+
+	pc, err := reload()
+	if err != nil {
+		failed.items = make(map[string]string)
+		c.install(failed, reload)
+		return
+	}
+
+	c.install(pc, reload)
+	numItemsFound = len(pc.items)
+	c.logf("goutils.ConfigReader.Read(%s) success, decoded %d items", pc.path, numItemsFound)
+	return
+}
+
+//install replaces the configured items at once and returns the previous ones
+func (c *ConfigReader) install(pc *parsedConfig, reload func() (*parsedConfig, error)) (previous map[string]string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	previous = c.items
+	c.configFilePath = pc.path
+	c.savePath = pc.savePath
+	c.items = pc.items
+	c.sections = pc.sections
+	c.lines = pc.lines
+	c.origins = pc.origins
+	c.history = pc.history
+	c.warnings = pc.warnings
+	c.files = pc.files
+	c.activeProfile = pc.profile
+	c.profiles = pc.profiles
+	c.format = pc.format
+	c.noLines = pc.noLines
+	c.reload = reload
+	return
+}
+
+//configLoader reads one or more config files merging their items
+type configLoader struct {
+	c     *ConfigReader
+	pc    *parsedConfig
+	fsys  fs.FS    // file system of the files, nil for the os one
+	stack []string // paths of the files being read, to detect include cycles
+
+	expanded bool // values already expanded while read, skips interpolation
+
+	known map[string]bool // profile names: selected, declared or used in a section header
+	found map[string]bool // profiles found
+	layer []profileValue  // values of the selected profile read in the current layer
+	depth int             // texts being read, 1 for a layer and more for its includes
+}
+
+//newLoader returns a loader reading from fsys (nil for the os file system),
+//with the defaults already loaded
+func (c *ConfigReader) newLoader(fsys fs.FS) (l *configLoader, err error) {
+	l = &configLoader{c: c, fsys: fsys, pc: &parsedConfig{items: make(map[string]string), origins: make(map[string]itemOrigin), history: make(map[string][]itemOrigin), profile: c.selectedProfile()}}
+	l.knowProfiles()
+	err = l.readDefaults()
+	return
+}
+
+//finish checks and completes what has been read
+func (l *configLoader) finish() (pc *parsedConfig, err error) {
+	c := l.c
+	if c.strict && len(l.pc.warnings) > 0 {
+		err = ParseErrors(l.pc.warnings)
+		c.logf("goutils.ConfigReader.Read strict parsing failed: %s", err)
+		return
+	}
+
+	l.applyProfile()
+	l.warnDeprecatedKeys()
+
+	interpolate := c.interpolation && !l.expanded
+	if err = l.decryptItems(interpolate); err == nil {
+		err = l.checkEnvOverrides()
+	}
+	if err != nil {
+		c.logf("goutils.ConfigReader.Read(%s) decryption error: %s", l.pc.path, err)
+		err = fmt.Errorf("goutils.ConfigReader.Read(%s): %w", l.pc.path, err)
+		return
+	}
+
+	if interpolate {
+		if l.pc.items, err = c.interpolateItems(l.pc.items); err != nil {
+			c.logf("goutils.ConfigReader.Read(%s) interpolation error: %s", l.pc.path, err)
+			err = fmt.Errorf("goutils.ConfigReader.Read(%s): %v", l.pc.path, err)
+			return
+		}
+	}
 
-	c.configFilePath = configPath
-	c.items = make(map[string]string)
+	pc = l.pc
+	return
+}
+
+//parseFiles reads configuration files in order, later files overriding earlier ones,
+//without changing the configured items. The lines of the last file are kept.
+//dirs are folders polled by Watch for added or removed files
+func (c *ConfigReader) parseFiles(paths []string, dirs []string) (pc *parsedConfig, err error) {
 
-	var file *os.File
-	file, err = os.Open(configPath)
+	l, err := c.newLoader(nil)
 	if err != nil {
-		log.Printf("goutils.ConfigReader.Read(%s) open error: %s", configPath, err)
+		return
+	}
+	for i, p := range paths {
+		if err = l.readFile(p, "", i == len(paths)-1); err != nil {
+			return
+		}
+	}
+	for _, d := range dirs {
+		info, _ := os.Stat(d)
+		l.pc.files = append(l.pc.files, watchedFile{path: d, info: info})
+	}
+	if len(paths) > 0 {
+		l.pc.path = paths[len(paths)-1]
+		l.pc.savePath = l.pc.path
+	}
+	return l.finish()
+}
+
+//readFile reads a configuration file starting in section, keepLines keeps its lines to write it back
+func (l *configLoader) readFile(configPath string, section string, keepLines bool) (err error) {
+
+	id := path.Clean(configPath)
+	if l.fsys == nil {
+		if id, err = filepath.Abs(configPath); err != nil {
+			return
+		}
+	}
+	for i, p := range l.stack {
+		if p == id {
+			chain := append(append([]string(nil), l.stack[i:]...), id)
+			err = fmt.Errorf("goutils.ConfigReader.Read(%s) include cycle: %s", configPath, strings.Join(chain, " -> "))
+			l.c.logf("%s", err)
+			return
+		}
+	}
+	l.stack = append(l.stack, id)
+	defer func() { l.stack = l.stack[:len(l.stack)-1] }()
+
+	var file fs.File
+	if l.fsys == nil {
+		file, err = os.Open(configPath)
+	} else {
+		file, err = l.fsys.Open(configPath)
+	}
+	if err != nil {
+		l.c.logf("goutils.ConfigReader.Read(%s) open error: %s", configPath, err)
 		return
 	}
 	defer file.Close()
 
-	tempItems := make(map[string]string)
+	var info os.FileInfo
+	if info, err = file.Stat(); err != nil {
+		l.c.logf("goutils.ConfigReader.Read(%s) stat error: %s", configPath, err)
+		return
+	}
+	l.pc.files = append(l.pc.files, watchedFile{path: configPath, info: info, fsys: l.fsys})
 
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		t := scanner.Text()
-		if len(t) > 0 && t[0] != '#' {
+	return l.readLines(file, configPath, section, keepLines, ConfigSourceFile)
+}
 
-			if strings.Contains(t, "=") {
+//resolveInclude returns the path of a file included by from
+func (l *configLoader) resolveInclude(from, include string) string {
+	if l.fsys != nil {
+		return path.Join(path.Dir(from), include)
+	}
+	if filepath.IsAbs(include) {
+		return include
+	}
+	return filepath.Join(filepath.Dir(from), include)
+}
 
-				a := strings.SplitN(t, "=", 2)
+//readLines reads the lines of a configuration named configPath starting in section,
+//keepLines keeps its lines to write it back
+func (l *configLoader) readLines(r io.Reader, configPath string, section string, keepLines bool, source ConfigSource) (err error) {
 
-				if len(a) == 2 {
-					//items += 1
-					tempItems[a[0]] = a[1]
-					log.Printf("from '%s' decoded key: '%v', value: '%v'", t, a[0], a[1])
+	l.depth++
+	defer func() { l.depth-- }()
 
-				} else {
-					log.Printf("goutils.ConfigReader.Read(%s) scan invalid line: '%s'", configPath, t)
-				}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		l.c.logf("goutils.ConfigReader.Read(%s) read error: %s", configPath, err)
+		return
+	}
+	text, format, err := l.decode(data, configPath)
+	if err != nil {
+		l.c.logf("goutils.ConfigReader.Read(%s) decode error: %s", configPath, err)
+		return
+	}
+	if keepLines {
+		l.pc.format = format
+	}
 
-			} else {
-				log.Printf("goutils.ConfigReader.Read(%s) scan invalid line: '%s'", configPath, t)
+	lineNumber := 0
+	seen := make(map[string]int) // line of each key, to report duplicates
+	lr := newLineReader(strings.NewReader(text), l.c.lineLimit())
+	for {
+		t, oversized, ok := lr.next()
+		if !ok {
+			break
+		}
+		lineNumber++
+		raw, first := t, lineNumber
+		for l.c.parserMode == ParserModeStandard && !oversized && continuesLine(t) {
+			next, nextOversized, ok := lr.next()
+			if !ok {
+				break
+			}
+			lineNumber++
+			raw += "\n" + next
+			t = joinContinuation(t, next)
+			oversized = nextOversized
+		}
+
+		line := configLine{text: raw, section: section}
+		pl := l.c.parseLine(t)
+		if oversized {
+			pl = parsedLine{kind: lineInvalid, column: l.c.lineLimit() + 1, reason: fmt.Sprintf("line longer than %d bytes", l.c.lineLimit())}
+		}
+		switch pl.kind {
+
+		case lineInclude:
+			if err = l.readFile(l.resolveInclude(configPath, pl.name), section, false); err != nil {
+				return
+			}
+
+		case lineSection:
+			section = pl.name
+			line.section = section
+			l.knowSectionProfile(section)
+			if !containsString(l.pc.sections, section) {
+				l.pc.sections = append(l.pc.sections, section)
 			}
+
+		case lineKeyValue:
+			itemName := sectionKey(section, pl.name)
+			if previous, duplicate := seen[itemName]; duplicate {
+				l.addProblem(configPath, first, pl.column, l.c.maskLine(t), fmt.Sprintf("duplicate key '%s', first defined at line %d", itemName, previous))
+			}
+			seen[itemName] = first
+
+			//items += 1
+			l.define(itemName, pl.value, itemOrigin{source: source, path: configPath, line: first})
+			line.key = pl.name
+			line.valueStart, line.valueEnd = pl.valueStart, pl.valueEnd
+			if raw != t {
+				// continued line: Set replaces everything after the start of the value
+				line.valueEnd = len(raw)
+			}
+			l.c.logf("goutils.ConfigReader.Read(%s) line %d decoded key: '%v', value: '%v'", configPath, first, itemName, l.c.maskValue(itemName, pl.value))
+
+		case lineInvalid:
+			l.c.logf("goutils.ConfigReader.Read(%s) scan invalid line %d: '%s'", configPath, first, l.c.maskLine(t))
+			l.addProblem(configPath, first, pl.column, l.c.maskLine(t), pl.reason)
+		}
+		if keepLines {
+			l.pc.lines = append(l.pc.lines, line)
 		}
 	}
 
-	if err = scanner.Err(); err != nil {
-		log.Printf("goutils.ConfigReader.Read(%s) scan error: %s", configPath, err)
+	if err = lr.err; err != nil {
+		l.c.logf("goutils.ConfigReader.Read(%s) scan error: %s", configPath, err)
 		return
 	}
+	if l.depth == 1 {
+		// end of a layer, not of an included file
+		l.applyLayerProfile()
+	}
+	return
+}
 
-	numItemsFound = len(tempItems)
-	c.items = tempItems
-	log.Printf("goutils.ConfigReader.Read(%s) success, decoded %d items", configPath, numItemsFound)
+//lineKind tells what a line of a config file contains
+type lineKind int
+
+const (
+	lineBlank    lineKind = iota // empty or comment
+	lineSection                  // '[name]'
+	lineKeyValue                 // 'key=value'
+	lineInclude                  // '!include path' or 'include=path'
+	lineInvalid
+)
+
+//parsedLine is the content of a line of a config file
+type parsedLine struct {
+	kind                 lineKind
+	name                 string // section name, key or include path
+	value                string
+	valueStart, valueEnd int    // position of the value in the line
+	column               int    // 1 based column of the key, or of the problem of an invalid line
+	reason               string // why the line is invalid
+}
+
+//parseLine decodes a line according to the parser mode
+func (c *ConfigReader) parseLine(t string) parsedLine {
+	if c.parserMode == ParserModeStandard {
+		return parseStandardLine(t)
+	}
+	return parseLegacyLine(t)
+}
+
+//parseLegacyLine decodes a line the original way: '#' comments at column 0 only,
+//key and value split on the first '=' keeping any space around them
+func parseLegacyLine(t string) parsedLine {
+	if strings.TrimSpace(t) == "" || t[0] == '#' {
+		return parsedLine{kind: lineBlank}
+	}
+	if include, ok := parseIncludeDirective(t); ok {
+		return parsedLine{kind: lineInclude, name: include}
+	}
+	if name, ok := parseSectionHeader(t); ok {
+		return parsedLine{kind: lineSection, name: name}
+	}
+	if i := strings.IndexByte(t, '='); i >= 0 {
+		return parsedLine{kind: lineKeyValue, name: t[:i], value: t[i+1:], valueStart: i + 1, valueEnd: len(t), column: 1}
+	}
+	return parsedLine{kind: lineInvalid, column: len(t) + 1, reason: "missing '='"}
+}
+
+//origin returns where an item was read from, items without origin have been set by the program
+func (c *ConfigReader) origin(itemName string) itemOrigin {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	if o, ok := c.origins[itemName]; ok {
+		return o
+	}
+	return itemOrigin{source: ConfigSourceFile}
+}
+
+//currentItems returns the map of the configured items
+//the map is never modified once installed, so it can be used without holding the lock
+func (c *ConfigReader) currentItems() map[string]string {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.items
+}
+
+//hasItems tells whether a configuration has been read
+func (c *ConfigReader) hasItems() bool {
+	return c.currentItems() != nil
+}
+
+//lookup returns the raw value of a configured item
+func (c *ConfigReader) lookup(itemName string) (itemValue string, found bool) {
+	itemValue, _, found = c.lookupSource(itemName)
+	return
+}
+
+//lookupSource returns the raw value of a configured item and where it comes from,
+//falling back to its deprecated names
+func (c *ConfigReader) lookupSource(itemName string) (itemValue string, source ConfigSource, found bool) {
+	if itemValue, source, found = c.lookupItem(itemName); !found && c.aliases != nil {
+		itemValue, source, found = c.lookupAlias(itemName)
+	}
+	return
+}
+
+//lookupItem returns the raw value of a configured item and where it comes from
+func (c *ConfigReader) lookupItem(itemName string) (itemValue string, source ConfigSource, found bool) {
+	if itemValue, found = c.lookupEnv(itemName); found {
+		source = ConfigSourceEnv
+		return
+	}
+	if itemValue, found = c.currentItems()[itemName]; found {
+		source = c.origin(itemName).source
+	}
 	return
 }
 
 //GetString get the string value of a configured item
 func (c *ConfigReader) GetString(itemName string) (itemValue string, found bool) {
-	if c.items != nil {
-		itemValue, found = c.items[itemName]
+	if c.hasItems() {
+		itemValue, found = c.lookup(itemName)
 	} else {
-		log.Printf("goutils.ConfigReader.GetString failed, there are no items read")
+		c.logf("goutils.ConfigReader.GetString failed, there are no items read")
 	}
 	return
 }
 
 //GetInt get the integer value 32 bit of a configured item
 func (c *ConfigReader) GetInt(itemName string) (itemValue int, found bool) {
-	if c.items != nil {
+	if c.hasItems() {
 		stemp := ""
-		stemp, found = c.items[itemName]
+		stemp, found = c.lookup(itemName)
 		if found {
 			i64, err := strconv.ParseInt(stemp, 10, 32)
 			if err == nil {
@@ -89,20 +531,20 @@ func (c *ConfigReader) GetInt(itemName string) (itemValue int, found bool) {
 				found = true
 			} else {
 				found = false
-				log.Printf("goutils.ConfigReader.GetInt found item but failed conversion to integer: '%v'", err)
+				c.logf("goutils.ConfigReader.GetInt found item but failed conversion to integer: '%v'", c.maskError(itemName, err))
 			}
 		}
 	} else {
-		log.Printf("goutils.ConfigReader.GetInt failed, there are no items read")
+		c.logf("goutils.ConfigReader.GetInt failed, there are no items read")
 	}
 	return
 }
 
 //GetInt64 get the integer 64 value of a configured item
 func (c *ConfigReader) GetInt64(itemName string) (itemValue int64, found bool) {
-	if c.items != nil {
+	if c.hasItems() {
 		stemp := ""
-		stemp, found = c.items[itemName]
+		stemp, found = c.lookup(itemName)
 		if found {
 			i64, err := strconv.ParseInt(stemp, 10, 64)
 			if err == nil {
@@ -110,20 +552,20 @@ func (c *ConfigReader) GetInt64(itemName string) (itemValue int64, found bool) {
 				found = true
 			} else {
 				found = false
-				log.Printf("goutils.ConfigReader.GetInt64 found item but failed conversion to integer: '%v'", err)
+				c.logf("goutils.ConfigReader.GetInt64 found item but failed conversion to integer: '%v'", c.maskError(itemName, err))
 			}
 		}
 	} else {
-		log.Printf("goutils.ConfigReader.GetInt64 failed, there are no items read")
+		c.logf("goutils.ConfigReader.GetInt64 failed, there are no items read")
 	}
 	return
 }
 
 //GetBool get the boolean value of a configured item
 func (c *ConfigReader) GetBool(itemName string) (itemValue bool, found bool) {
-	if c.items != nil {
+	if c.hasItems() {
 		stemp := ""
-		stemp, found = c.items[itemName]
+		stemp, found = c.lookup(itemName)
 		if found {
 			b, err := strconv.ParseBool(stemp)
 			if err == nil {
@@ -131,21 +573,21 @@ func (c *ConfigReader) GetBool(itemName string) (itemValue bool, found bool) {
 				found = true
 			} else {
 				found = false
-				log.Printf("goutils.ConfigReader.GetBool found item but failed conversion to bool: '%v'", err)
+				c.logf("goutils.ConfigReader.GetBool found item but failed conversion to bool: '%v'", c.maskError(itemName, err))
 			}
 		}
 	} else {
-		log.Printf("goutils.ConfigReader.GetBool failed, there are no items read")
+		c.logf("goutils.ConfigReader.GetBool failed, there are no items read")
 	}
 	return
 }
 
 //CountItems get the total number of counfigured items
 func (c *ConfigReader) CountItems() (numItemsFound int) {
-	if c.items != nil {
-		numItemsFound = len(c.items)
+	if items := c.currentItems(); items != nil {
+		numItemsFound = len(items)
 	} else {
-		log.Printf("goutils.ConfigReader.CountItems failed, there are no items read")
+		c.logf("goutils.ConfigReader.CountItems failed, there are no items read")
 	}
 	return
 }
@@ -153,23 +595,118 @@ func (c *ConfigReader) CountItems() (numItemsFound int) {
 // PrintItems print all items found while reading the config
 // The parameter toLog directs the output to the log when it is true otherwise to the console
 func (c *ConfigReader) PrintItems(toLog bool) {
-	if c.items != nil {
+	if items := c.currentItems(); items != nil {
+		configPath := c.ConfigFilePath()
 		if toLog {
-			log.Printf("Elements in [%s]:\n", c.configFilePath)
+			log.Printf("Elements in [%s]:\n", configPath)
 		} else {
-			fmt.Printf("Elements in [%s]:\n", c.configFilePath)
+			fmt.Printf("Elements in [%s]:\n", configPath)
+		}
+		keys := make([]string, 0, len(items))
+		for k := range items {
+			keys = append(keys, k)
 		}
-		i := 0
-		for k, v := range c.items {
-			i++
+		sort.Strings(keys)
+		for i, k := range keys {
+			e := c.Explain(k)
+			from, overrides := "", ""
+			if n := len(e.Chain); n > 0 {
+				from = e.Chain[n-1].String()
+				for j, o := range e.Chain[:n-1] {
+					if j > 0 {
+						overrides += ", "
+					}
+					overrides += o.String()
+				}
+			}
+			if overrides != "" {
+				overrides = " overrides[" + overrides + "]"
+			}
 			if toLog {
-				log.Printf("- [%d/%d] key[%s] value[%s]\n", i, len(c.items), k, v)
+				log.Printf("- [%d/%d] key[%s] value[%s] source[%s]%s\n", i+1, len(items), k, e.Value, from, overrides)
 			} else {
-				fmt.Printf("- [%d/%d] key[%s] value[%s]\n", i, len(c.items), k, v)
+				fmt.Printf("- [%d/%d] key[%s] value[%s] source[%s]%s\n", i+1, len(items), k, e.Value, from, overrides)
 			}
 		}
 	} else {
-		log.Printf("goutils.ConfigReader.GetString failed, there are no items read")
+		c.logf("goutils.ConfigReader.GetString failed, there are no items read")
+	}
+	return
+}
+
+//parseSectionHeader recognizes a '[name]' line and returns the trimmed section name
+func parseSectionHeader(line string) (name string, ok bool) {
+	t := strings.TrimSpace(line)
+	if len(t) < 2 || t[0] != '[' || t[len(t)-1] != ']' {
+		return
 	}
+	name = strings.TrimSpace(t[1 : len(t)-1])
+	ok = len(name) > 0
 	return
 }
+
+//sectionKey returns the qualified item name of a key inside a section
+func sectionKey(section, key string) string {
+	if section == "" {
+		return key
+	}
+	return section + "." + key
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+//Sections returns the names of the sections found while reading the config, in file order
+func (c *ConfigReader) Sections() []string {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return append([]string(nil), c.sections...)
+}
+
+//GetSectionString get the string value of a key inside a section
+func (c *ConfigReader) GetSectionString(section, key string) (itemValue string, found bool) {
+	return c.GetString(sectionKey(section, key))
+}
+
+//Section returns a view over the keys of a section
+//An empty name gives the keys found before any section header
+func (c *ConfigReader) Section(name string) *ConfigSection {
+	return &ConfigSection{reader: c, name: name}
+}
+
+//ConfigSection is a view over the keys of one section of a ConfigReader
+type ConfigSection struct {
+	reader *ConfigReader
+	name   string
+}
+
+//Name returns the section name
+func (s *ConfigSection) Name() string {
+	return s.name
+}
+
+//GetString get the string value of a key in the section
+func (s *ConfigSection) GetString(key string) (itemValue string, found bool) {
+	return s.reader.GetString(sectionKey(s.name, key))
+}
+
+//GetInt get the integer value 32 bit of a key in the section
+func (s *ConfigSection) GetInt(key string) (itemValue int, found bool) {
+	return s.reader.GetInt(sectionKey(s.name, key))
+}
+
+//GetInt64 get the integer 64 value of a key in the section
+func (s *ConfigSection) GetInt64(key string) (itemValue int64, found bool) {
+	return s.reader.GetInt64(sectionKey(s.name, key))
+}
+
+//GetBool get the boolean value of a key in the section
+func (s *ConfigSection) GetBool(key string) (itemValue bool, found bool) {
+	return s.reader.GetBool(sectionKey(s.name, key))
+}
@@ -0,0 +1,219 @@
+package goutils
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+/*
+
+config_ini_alias.go
+
+keys renamed in a new version keep working with their old name:
+
+			conf := goutils.NewConfigReader(
+				goutils.WithKeyAlias("db_host", "database.host", "3.0"),
+				goutils.WithKeyAlias("db_port", "database.port", "3.0"),
+			)
+			conf.Read("old.ini")                 // db_host=localhost
+			conf.GetString("database.host")      // localhost, with a deprecation warning
+
+when the new key is missing its old names are looked up; the first time a key is read
+through an old name a warning goes to the logger (see WithLogger). Every old name found
+by Read is reported by Warnings as well, whatever the logger:
+
+			for _, w := range conf.Warnings() {
+				log.Print(w)   // old.ini:1:0: deprecated key 'db_host', use 'database.host' ...
+			}
+
+MigrateFile rewrites a file with the new names, keeping comments and order:
+
+			migrated, err := conf.MigrateFile("/etc/myapp/app.ini")
+			// [db_host -> database.host db_port -> database.port]
+
+*/
+
+// keyAlias is an old name of a key
+type keyAlias struct {
+	oldName  string
+	newName  string
+	removeIn string // version removing the old name, can be empty
+}
+
+// aliasWarnings remembers the deprecated keys already reported, shared with the snapshots
+type aliasWarnings struct {
+	lock   sync.Mutex
+	warned map[string]bool
+}
+
+// WithKeyAlias registers oldName as a deprecated name of newName, removeIn is the version
+// that will stop reading it and can be empty. Several old names can be given to a key
+func WithKeyAlias(oldName, newName, removeIn string) ConfigOption {
+	return func(c *ConfigReader) {
+		if c.aliases == nil {
+			c.aliases = make(map[string][]keyAlias)
+			c.aliasWarnings = &aliasWarnings{warned: make(map[string]bool)}
+		}
+		c.aliases[newName] = append(c.aliases[newName], keyAlias{oldName: oldName, newName: newName, removeIn: removeIn})
+	}
+}
+
+// lookupAlias returns the value of an item read through one of its old names
+func (c *ConfigReader) lookupAlias(itemName string) (itemValue string, source ConfigSource, found bool) {
+	for _, a := range c.aliases[itemName] {
+		if itemValue, source, found = c.lookupItem(a.oldName); found {
+			c.warnDeprecated(a, source)
+			return
+		}
+	}
+	return
+}
+
+// warnDeprecated reports the use of an old name to the logger, once
+func (c *ConfigReader) warnDeprecated(a keyAlias, source ConfigSource) {
+	if c.logger == nil {
+		// nowhere to write it, Warnings has it
+		return
+	}
+	w := c.aliasWarnings
+	w.lock.Lock()
+	warned := w.warned[a.oldName]
+	w.warned[a.oldName] = true
+	w.lock.Unlock()
+	if warned {
+		return
+	}
+	where := ""
+	switch o := c.origin(a.oldName); {
+	case source == ConfigSourceEnv:
+		where = " (environment " + c.EnvName(a.oldName) + ")"
+	case o.line > 0:
+		where = fmt.Sprintf(" (%s:%d)", o.path, o.line)
+	}
+	removal := ""
+	if a.removeIn != "" {
+		removal = ", it will be removed in " + a.removeIn
+	}
+	c.logf("goutils.ConfigReader: key '%s'%s is deprecated, use '%s'%s", a.oldName, where, a.newName, removal)
+}
+
+// warnDeprecatedKeys adds a warning for each old name set in the files or in the environment
+func (l *configLoader) warnDeprecatedKeys() {
+	var newNames []string
+	for newName := range l.c.aliases {
+		newNames = append(newNames, newName)
+	}
+	sort.Strings(newNames)
+	for _, newName := range newNames {
+		_, newSet := l.pc.items[newName]
+		if !newSet {
+			_, newSet = l.c.lookupRawEnv(newName)
+		}
+		for _, a := range l.c.aliases[newName] {
+			reason := fmt.Sprintf("deprecated key '%s', use '%s'", a.oldName, a.newName)
+			if a.removeIn != "" {
+				reason += ", it will be removed in " + a.removeIn
+			}
+			if newSet {
+				reason += "; ignored, '" + a.newName + "' is set"
+			}
+			if _, set := l.pc.items[a.oldName]; set {
+				o := l.pc.origins[a.oldName]
+				l.addProblem(o.path, o.line, 0, a.oldName, reason)
+			} else if _, set := l.c.lookupRawEnv(a.oldName); set {
+				l.addProblem("environment "+l.c.EnvName(a.oldName), 0, 0, a.oldName, reason)
+			}
+		}
+	}
+}
+
+// MigrateFile rewrites a config file replacing the old names of the keys with the new ones,
+// keeping comments and key order; a key moved to another section is added to that section.
+// When both names are in the file the old one is dropped. It returns the renames done
+func (c *ConfigReader) MigrateFile(configPath string) (migrated []string, err error) {
+	renames := make(map[string]string)
+	for newName, aliases := range c.aliases {
+		for _, a := range aliases {
+			renames[a.oldName] = newName
+		}
+	}
+
+	l, err := c.newLoader(nil)
+	if err != nil {
+		return
+	}
+	if err = l.readFile(configPath, "", true); err != nil {
+		return nil, fmt.Errorf("goutils.ConfigReader.MigrateFile(%s): %v", configPath, err)
+	}
+	lines := l.pc.lines
+	present := make(map[string]bool)
+	for _, line := range lines {
+		if line.key != "" {
+			present[sectionKey(line.section, line.key)] = true
+		}
+	}
+	sections := l.pc.sections
+
+	var kept, moved []configLine
+	for _, line := range lines {
+		newName, rename := renames[sectionKey(line.section, line.key)]
+		if line.key == "" || !rename {
+			kept = append(kept, line)
+			continue
+		}
+		oldName := sectionKey(line.section, line.key)
+		if present[newName] {
+			migrated = append(migrated, oldName+" dropped, "+newName+" already set")
+			continue
+		}
+		present[newName] = true
+		migrated = append(migrated, oldName+" -> "+newName)
+		section, key := splitSectionKey(sections, newName)
+		if s := sectionOfKey(newName); section == "" && s != "" {
+			// a section not in the file yet
+			section, key = s, newName[len(s)+1:]
+		}
+		if section == line.section {
+			at := strings.Index(line.text, line.key)
+			line.text = line.text[:at] + key + line.text[at+len(line.key):]
+			line.valueStart += len(key) - len(line.key)
+			line.valueEnd += len(key) - len(line.key)
+			line.key = key
+			kept = append(kept, line)
+			continue
+		}
+		value := line.text[line.valueStart:line.valueEnd]
+		moved = append(moved, configLine{text: key + "=" + value, section: section, key: key, valueStart: len(key) + 1, valueEnd: len(key) + 1 + len(value)})
+	}
+	if len(migrated) == 0 {
+		return
+	}
+
+	for _, line := range moved {
+		if line.section != "" && !containsString(sections, line.section) {
+			sections = append(sections, line.section)
+			kept = append(kept, configLine{text: ""}, configLine{text: "[" + line.section + "]", section: line.section})
+		}
+		at := insertPosition(kept, line.section)
+		kept = append(kept, configLine{})
+		copy(kept[at+1:], kept[at:])
+		kept[at] = line
+	}
+
+	var b strings.Builder
+	for _, line := range kept {
+		b.WriteString(line.text)
+		b.WriteString("\n")
+	}
+	data, err := encodeConfig(b.String(), l.pc.format)
+	if err == nil {
+		err = writeFileAtomic(configPath, data)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("goutils.ConfigReader.MigrateFile(%s): %v", configPath, err)
+	}
+	c.logf("goutils.ConfigReader.MigrateFile(%s) migrated %d keys", configPath, len(migrated))
+	return
+}
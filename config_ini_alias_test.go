@@ -0,0 +1,63 @@
+package goutils
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+)
+
+// recordLogger keeps the lines written to it
+type recordLogger struct{ lines []string }
+
+func (r *recordLogger) Printf(format string, v ...interface{}) {
+	r.lines = append(r.lines, fmt.Sprintf(format, v...))
+}
+
+func TestKeyAliasWarnings(t *testing.T) {
+	t.Setenv(DefaultProfileEnv, "")
+	paths := writeConfigFiles(t, "old.ini", "db_host=localhost\nport=1\n")
+	c := NewConfigReader(WithKeyAlias("db_host", "database.host", "3.0"))
+	if _, err := c.Read(paths[0]); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := c.GetString("database.host"); got != "localhost" {
+		t.Errorf("database.host = %q", got)
+	}
+	w := c.Warnings()
+	if len(w) != 1 || w[0].Line != 1 || !strings.Contains(w[0].Reason, "deprecated key 'db_host', use 'database.host', it will be removed in 3.0") {
+		t.Errorf("Warnings() = %v", w)
+	}
+
+	logger := &recordLogger{}
+	c = NewConfigReader(WithKeyAlias("db_host", "database.host", ""), WithLogger(logger))
+	c.Read(paths[0])
+	c.GetString("database.host")
+	c.GetString("database.host")
+	n := 0
+	for _, l := range logger.lines {
+		if strings.Contains(l, "is deprecated") {
+			n++
+		}
+	}
+	if n != 1 {
+		t.Errorf("%d deprecation warnings logged, want 1", n)
+	}
+}
+
+func TestMigrateFile(t *testing.T) {
+	t.Setenv(DefaultProfileEnv, "")
+	paths := writeConfigFiles(t, "old.ini", "# db\ndb_host=h\ndb_port=1\nname=n\n")
+	c := NewConfigReader(WithKeyAlias("db_host", "database.host", ""), WithKeyAlias("db_port", "database.port", ""))
+	migrated, err := c.MigrateFile(paths[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(migrated) != 2 {
+		t.Errorf("migrated %v", migrated)
+	}
+	data, _ := os.ReadFile(paths[0])
+	if got, want := string(data), "# db\nname=n\n\n[database]\nhost=h\nport=1\n"; got != want {
+		t.Errorf("migrated file %q, want %q", got, want)
+	}
+}
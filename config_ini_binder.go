@@ -0,0 +1,204 @@
+package goutils
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+)
+
+/*
+
+config_ini_binder.go
+
+ConfigBinder registers a setting once and takes it from a command line flag, an
+environment variable, the configuration file or a default:
+
+			conf := goutils.NewConfigReader()
+			conf.Read("/etc/myapp/app.ini")
+
+			binder := goutils.NewConfigBinder(conf, flag.CommandLine)
+			binder.Bind("database.port", "db-port", "MYAPP_DB_PORT", "5432", "database port")
+			binder.Bind("log_level", "log-level", "", "info", "log level")
+			flag.Parse()
+
+			settings, report, err := binder.Resolve()
+			port, _ := settings.GetInt("database.port")
+			log.Print(report)
+
+precedence, from the highest:
+
+			1. flag set explicitly on the command line
+			2. environment variable
+			3. configuration file (or an env override of the ConfigReader, see WithEnvPrefix)
+			4. default given to Bind
+
+a flag already defined on the FlagSet, e.g. with flag.Int, is used as it is
+
+*/
+
+// ConfigSourceFlag is the source of a value set by a command line flag
+const ConfigSourceFlag ConfigSource = "flag"
+
+// ConfigBinding is a setting registered with ConfigBinder.Bind
+type ConfigBinding struct {
+	Key     string // item name in the ConfigReader
+	Flag    string // flag name, empty when not bound to a flag
+	Env     string // environment variable, empty when not bound to a variable
+	Default string // value used when no other source sets it
+	Usage   string
+}
+
+// BoundValue is the effective value of a setting and where it comes from
+type BoundValue struct {
+	Key    string
+	Value  string       // masked for secret keys
+	Source ConfigSource // ConfigSourceNone when no source sets it and there is no default
+	From   string       // '-flag', environment variable, 'file:line' or 'default'
+}
+
+// BindReport lists the effective value of every setting in the order they were bound
+type BindReport []BoundValue
+
+// ConfigBinder resolves settings from flags, environment, configuration and defaults
+type ConfigBinder struct {
+	conf     *ConfigReader
+	flags    *flag.FlagSet
+	bindings []ConfigBinding
+}
+
+// NewConfigBinder returns a binder taking values from conf and flags, flags can be nil
+func NewConfigBinder(conf *ConfigReader, flags *flag.FlagSet) *ConfigBinder {
+	return &ConfigBinder{conf: conf, flags: flags}
+}
+
+// Bind registers a setting; flagName and envName can be empty. A string flag is defined
+// on the FlagSet unless a flag with that name already exists, so Bind goes before Parse
+func (b *ConfigBinder) Bind(key, flagName, envName, defValue, usage string) error {
+	if key == "" {
+		return errors.New("goutils.ConfigBinder.Bind: empty key")
+	}
+	for _, bd := range b.bindings {
+		if bd.Key == key {
+			return fmt.Errorf("goutils.ConfigBinder.Bind(%s): key already bound", key)
+		}
+	}
+	if flagName != "" {
+		if b.flags == nil {
+			return fmt.Errorf("goutils.ConfigBinder.Bind(%s): flag '%s' without FlagSet", key, flagName)
+		}
+		if b.flags.Lookup(flagName) == nil {
+			b.flags.String(flagName, defValue, usage)
+		}
+	}
+	b.bindings = append(b.bindings, ConfigBinding{Key: key, Flag: flagName, Env: envName, Default: defValue, Usage: usage})
+	return nil
+}
+
+// Bindings returns the registered settings
+func (b *ConfigBinder) Bindings() []ConfigBinding {
+	return append([]ConfigBinding(nil), b.bindings...)
+}
+
+// Resolve returns a read only ConfigReader with the effective value of every bound setting
+// and the report of where each value comes from; the FlagSet must have been parsed
+func (b *ConfigBinder) Resolve() (settings *ConfigReader, report BindReport, err error) {
+	if b.flags != nil && !b.flags.Parsed() {
+		return nil, nil, errors.New("goutils.ConfigBinder.Resolve: FlagSet not parsed yet")
+	}
+	explicit := make(map[string]bool)
+	if b.flags != nil {
+		b.flags.Visit(func(f *flag.Flag) { explicit[f.Name] = true })
+	}
+
+	conf := b.conf
+	if conf == nil {
+		conf = NewConfigReader()
+	}
+	snap := conf.Snapshot()
+	settings = &ConfigReader{
+		configFilePath: snap.configFilePath,
+		items:          make(map[string]string),
+		origins:        make(map[string]itemOrigin),
+		readOnly:       true,
+		configOptions:  snap.configOptions,
+	}
+	// the values are already resolved, env overrides must not be applied twice
+	settings.envPrefix = ""
+
+	for _, bd := range b.bindings {
+		bv := BoundValue{Key: bd.Key}
+		var value string
+		var o itemOrigin
+		envValue, envSet := lookupBoundEnv(bd.Env)
+		confValue, confSource, confFound := snap.lookupSource(bd.Key)
+		switch {
+		case bd.Flag != "" && explicit[bd.Flag]:
+			value, o = b.flags.Lookup(bd.Flag).Value.String(), itemOrigin{source: ConfigSourceFlag}
+			bv.From = "-" + bd.Flag
+		case envSet:
+			value, o = envValue, itemOrigin{source: ConfigSourceEnv}
+			bv.From = bd.Env
+		case confFound:
+			value, o = confValue, snap.origin(bd.Key)
+			o.source = confSource
+			bv.From = describeOrigin(snap, bd.Key, o)
+		case bd.Flag != "":
+			// a flag defined before Bind has its own default
+			value, o = b.flags.Lookup(bd.Flag).DefValue, itemOrigin{source: ConfigSourceDefault}
+			bv.From = "default"
+		case bd.Default != "":
+			value, o = bd.Default, itemOrigin{source: ConfigSourceDefault}
+			bv.From = "default"
+		}
+		if o.source != ConfigSourceNone {
+			settings.items[bd.Key] = value
+			settings.origins[bd.Key] = o
+			if section := sectionOfKey(bd.Key); section != "" && !containsString(settings.sections, section) {
+				settings.sections = append(settings.sections, section)
+			}
+		}
+		bv.Value, bv.Source = snap.maskValue(bd.Key, value), o.source
+		report = append(report, bv)
+	}
+	return
+}
+
+// lookupBoundEnv returns the value of a bound environment variable
+func lookupBoundEnv(name string) (string, bool) {
+	if name == "" {
+		return "", false
+	}
+	return os.LookupEnv(name)
+}
+
+// describeOrigin tells where a value of the ConfigReader comes from
+func describeOrigin(c *ConfigReader, key string, o itemOrigin) string {
+	switch {
+	case o.source == ConfigSourceEnv:
+		return c.EnvName(key)
+	case o.line > 0:
+		return fmt.Sprintf("%s:%d", o.path, o.line)
+	case o.path != "":
+		return o.path
+	}
+	return string(o.source)
+}
+
+// String formats the report as a table, one setting per line
+func (r BindReport) String() string {
+	var sb strings.Builder
+	w := tabwriter.NewWriter(&sb, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "KEY\tVALUE\tSOURCE\tFROM")
+	for _, bv := range r {
+		source := string(bv.Source)
+		if source == "" {
+			source = "-"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", bv.Key, bv.Value, source, bv.From)
+	}
+	w.Flush()
+	return sb.String()
+}
@@ -0,0 +1,274 @@
+package goutils
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+/*
+
+config_ini_crypt.go
+
+values starting with 'enc:v1:' are encrypted with AES-256-GCM and decrypted when read:
+
+			db_password=enc:v1:2Zq0b6l2mY0s3yF1...
+
+the 32 bytes key, written as base64 or hex, is taken from the environment variable
+GOUTILS_CONFIG_KEY unless another source is configured:
+
+			conf := goutils.NewConfigReader(goutils.WithDecryptionKeyFile("/etc/myapp/config.key"))
+			conf := goutils.NewConfigReader(goutils.WithDecryptionKeyEnv("MYAPP_CONFIG_KEY"))
+
+to create a key and encrypt a value:
+
+			keyText, _ := goutils.GenerateConfigKey()     // store it in the key file or variable
+			key, _ := goutils.ParseConfigKey(keyText)
+			value, _ := goutils.EncryptConfigValue(key, "s3cr3t") // enc:v1:...
+
+Read fails with ErrNoDecryptionKey when a value is encrypted and there is no key, and with
+ErrDecryption when the key is wrong or the value has been tampered with. Decrypted values
+are masked like the values of secret keys and never interpolated.
+An environment override (see WithEnvPrefix) can be encrypted as well, it is decrypted when
+read and Read fails as above when it overrides a key of the files and cannot be decrypted.
+
+the command goutils-configcrypt (cmd/goutils-configcrypt) creates keys and encrypts values:
+
+			goutils-configcrypt genkey > /etc/myapp/config.key
+			goutils-configcrypt encrypt -key-file /etc/myapp/config.key 's3cr3t'
+
+*/
+
+// DefaultDecryptionKeyEnv is the environment variable holding the key when no other source is configured
+const DefaultDecryptionKeyEnv = "GOUTILS_CONFIG_KEY"
+
+// encryptedPrefix marks an encrypted value, the version tells the algorithm
+const encryptedPrefix = "enc:v1:"
+
+var (
+	// ErrNoDecryptionKey is returned when an encrypted value is read without a key
+	ErrNoDecryptionKey = errors.New("no decryption key")
+	// ErrDecryption is returned when an encrypted value cannot be decrypted with the key
+	ErrDecryption = errors.New("cannot decrypt, wrong key or tampered value")
+)
+
+// WithDecryptionKey sets the key decrypting the enc:v1: values
+func WithDecryptionKey(key []byte) ConfigOption {
+	return func(c *ConfigReader) {
+		c.decryptionKey = append([]byte(nil), key...)
+	}
+}
+
+// WithDecryptionKeyEnv sets the environment variable holding the key, as base64 or hex
+func WithDecryptionKeyEnv(name string) ConfigOption {
+	return func(c *ConfigReader) {
+		c.decryptionKeyEnv = name
+	}
+}
+
+// WithDecryptionKeyFile sets the file holding the key, as base64 or hex. The file is read
+// at every Read and reload, so that a new key is used with the new values
+func WithDecryptionKeyFile(path string) ConfigOption {
+	return func(c *ConfigReader) {
+		c.decryptionKeyFile = path
+	}
+}
+
+// GenerateConfigKey returns a new random key as base64
+func GenerateConfigKey() (string, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return "", fmt.Errorf("goutils.GenerateConfigKey: %v", err)
+	}
+	return base64.StdEncoding.EncodeToString(key), nil
+}
+
+// ParseConfigKey decodes a 32 bytes key written as base64 or hex
+func ParseConfigKey(text string) ([]byte, error) {
+	text = strings.TrimSpace(text)
+	if key, err := hex.DecodeString(text); err == nil && len(key) == 32 {
+		return key, nil
+	}
+	if key, err := base64.StdEncoding.DecodeString(text); err == nil && len(key) == 32 {
+		return key, nil
+	}
+	return nil, errors.New("goutils.ParseConfigKey: the key must be 32 bytes written as base64 or hex")
+}
+
+// EncryptConfigValue returns plaintext encrypted with key as an enc:v1: value
+func EncryptConfigValue(key []byte, plaintext string) (string, error) {
+	gcm, err := newConfigCipher(key)
+	if err != nil {
+		return "", fmt.Errorf("goutils.EncryptConfigValue: %v", err)
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err = rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("goutils.EncryptConfigValue: %v", err)
+	}
+	sealed := gcm.Seal(nonce, nonce, []byte(plaintext), nil)
+	return encryptedPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// DecryptConfigValue returns the plaintext of an enc:v1: value
+func DecryptConfigValue(key []byte, value string) (string, error) {
+	plaintext, err := decryptValue(key, value)
+	if err != nil {
+		return "", fmt.Errorf("goutils.DecryptConfigValue: %w", err)
+	}
+	return plaintext, nil
+}
+
+// IsEncryptedValue tells whether value is an enc:v1: value
+func IsEncryptedValue(value string) bool {
+	return strings.HasPrefix(strings.TrimSpace(value), encryptedPrefix)
+}
+
+// newConfigCipher returns the AES-256-GCM cipher of key
+func newConfigCipher(key []byte) (cipher.AEAD, error) {
+	if len(key) != 32 {
+		return nil, fmt.Errorf("the key must be 32 bytes, not %d", len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// decryptValue decrypts an enc:v1: value
+func decryptValue(key []byte, value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if !strings.HasPrefix(value, encryptedPrefix) {
+		return "", fmt.Errorf("the value does not start with '%s'", encryptedPrefix)
+	}
+	sealed, err := base64.StdEncoding.DecodeString(value[len(encryptedPrefix):])
+	if err != nil {
+		return "", fmt.Errorf("invalid base64 in the encrypted value: %v", err)
+	}
+	gcm, err := newConfigCipher(key)
+	if err != nil {
+		return "", err
+	}
+	if len(sealed) < gcm.NonceSize()+gcm.Overhead() {
+		return "", fmt.Errorf("%w: the value is too short", ErrDecryption)
+	}
+	nonce, ciphertext := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", ErrDecryption
+	}
+	return string(plaintext), nil
+}
+
+// loadDecryptionKey returns the configured key, from the first source set
+func (c *ConfigReader) loadDecryptionKey() ([]byte, error) {
+	switch {
+	case c.decryptionKey != nil:
+		if len(c.decryptionKey) != 32 {
+			return nil, fmt.Errorf("decryption key must be 32 bytes, not %d", len(c.decryptionKey))
+		}
+		return c.decryptionKey, nil
+	case c.decryptionKeyFile != "":
+		data, err := os.ReadFile(c.decryptionKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrNoDecryptionKey, err)
+		}
+		key, err := ParseConfigKey(string(data))
+		if err != nil {
+			return nil, fmt.Errorf("decryption key file %s: %v", c.decryptionKeyFile, err)
+		}
+		return key, nil
+	}
+	name := c.decryptionKeyEnv
+	if name == "" {
+		name = DefaultDecryptionKeyEnv
+	}
+	text, ok := os.LookupEnv(name)
+	if !ok || strings.TrimSpace(text) == "" {
+		return nil, fmt.Errorf("%w: set %s, or use WithDecryptionKeyEnv or WithDecryptionKeyFile", ErrNoDecryptionKey, name)
+	}
+	key, err := ParseConfigKey(text)
+	if err != nil {
+		return nil, fmt.Errorf("decryption key variable %s: %v", name, err)
+	}
+	return key, nil
+}
+
+// decryptItems replaces the encrypted values read with their plaintext, escaping '$'
+// when the values are interpolated afterwards
+func (l *configLoader) decryptItems(escape bool) error {
+	var names []string
+	for k, v := range l.pc.items {
+		if IsEncryptedValue(v) {
+			names = append(names, k)
+		}
+	}
+	sort.Strings(names)
+
+	var key []byte
+	for _, k := range names {
+		v := l.pc.items[k]
+		o := l.pc.origins[k]
+		where := o.path
+		if o.line > 0 {
+			where = fmt.Sprintf("%s:%d", o.path, o.line)
+		}
+		if key == nil {
+			var err error
+			if key, err = l.c.loadDecryptionKey(); err != nil {
+				return fmt.Errorf("key '%s' (%s) is encrypted: %w", k, where, err)
+			}
+		}
+		plaintext, err := decryptValue(key, v)
+		if err != nil {
+			return fmt.Errorf("key '%s' (%s): %w", k, where, err)
+		}
+		if escape {
+			plaintext = strings.ReplaceAll(plaintext, "$", "$$")
+		}
+		l.pc.items[k] = plaintext
+		o.encrypted = true
+		l.pc.origins[k] = o
+	}
+	return nil
+}
+
+// decryptEnv decrypts the encrypted value of the environment variable overriding an item
+func (c *ConfigReader) decryptEnv(itemName, value string) (string, error) {
+	key, err := c.loadDecryptionKey()
+	if err == nil {
+		var plaintext string
+		if plaintext, err = decryptValue(key, value); err == nil {
+			return plaintext, nil
+		}
+	}
+	return "", fmt.Errorf("environment %s overriding '%s' is encrypted: %w", c.EnvName(itemName), itemName, err)
+}
+
+// checkEnvOverrides fails when an encrypted environment override of an item read cannot be decrypted
+func (l *configLoader) checkEnvOverrides() error {
+	if l.c.envPrefix == "" {
+		return nil
+	}
+	var names []string
+	for k := range l.pc.items {
+		if v, ok := l.c.lookupRawEnv(k); ok && IsEncryptedValue(v) {
+			names = append(names, k)
+		}
+	}
+	sort.Strings(names)
+	for _, k := range names {
+		v, _ := l.c.lookupRawEnv(k)
+		if _, err := l.c.decryptEnv(k, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
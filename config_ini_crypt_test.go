@@ -0,0 +1,108 @@
+package goutils
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestEncryptedValues(t *testing.T) {
+	t.Setenv(DefaultProfileEnv, "")
+	key := make([]byte, 32)
+	value, err := EncryptConfigValue(key, "s3cr$t")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := NewConfigReader(WithDecryptionKey(key), WithInterpolation(true))
+	if _, err := c.ReadReader(strings.NewReader("db_password="+value+"\n"), "test.ini"); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := c.GetString("db_password"); got != "s3cr$t" {
+		t.Errorf("db_password = %q", got)
+	}
+	if !c.IsSecret("db_password") {
+		t.Error("decrypted value not secret")
+	}
+
+	other := make([]byte, 32)
+	other[0] = 1
+	c = NewConfigReader(WithDecryptionKey(other))
+	if _, err := c.ReadReader(strings.NewReader("db_password="+value+"\n"), "test.ini"); !errors.Is(err, ErrDecryption) {
+		t.Errorf("Read with a wrong key: %v", err)
+	}
+	t.Setenv(DefaultDecryptionKeyEnv, "")
+	c = NewConfigReader()
+	if _, err := c.ReadReader(strings.NewReader("db_password="+value+"\n"), "test.ini"); !errors.Is(err, ErrNoDecryptionKey) {
+		t.Errorf("Read without key: %v", err)
+	}
+}
+
+func TestEncryptedEnvOverride(t *testing.T) {
+	t.Setenv(DefaultProfileEnv, "")
+	key := make([]byte, 32)
+	value, _ := EncryptConfigValue(key, "from env")
+	t.Setenv("APP_DB_PASSWORD", value)
+
+	c := NewConfigReader(WithEnvPrefix("APP_"), WithDecryptionKey(key))
+	if _, err := c.ReadReader(strings.NewReader("[db]\npassword=file\n"), "test.ini"); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := c.GetString("db.password"); got != "from env" {
+		t.Errorf("db.password = %q", got)
+	}
+	if !c.IsSecret("db.password") {
+		t.Error("encrypted env override not secret")
+	}
+
+	t.Setenv(DefaultDecryptionKeyEnv, "")
+	c = NewConfigReader(WithEnvPrefix("APP_"))
+	if _, err := c.ReadReader(strings.NewReader("[db]\npassword=file\n"), "test.ini"); !errors.Is(err, ErrNoDecryptionKey) {
+		t.Errorf("Read without key: %v", err)
+	}
+}
+
+func TestSetEncryptedValue(t *testing.T) {
+	t.Setenv(DefaultProfileEnv, "")
+	key := make([]byte, 32)
+	value, _ := EncryptConfigValue(key, "old")
+	paths := writeConfigFiles(t, "app.ini", "db_pass="+value+"\n")
+
+	c := NewConfigReader(WithDecryptionKey(key))
+	if _, err := c.Read(paths[0]); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Set("db_pass", "newpw"); err != nil {
+		t.Fatal(err)
+	}
+	if !c.IsSecret("db_pass") {
+		t.Error("value set over an encrypted one is not secret")
+	}
+	if data, _ := c.ToJSON(true); strings.Contains(string(data), "newpw") {
+		t.Errorf("ToJSON(true) shows the value: %s", data)
+	}
+	if err := c.Save(); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(paths[0])
+	if strings.Contains(string(data), "newpw") || !strings.HasPrefix(string(data), "db_pass=enc:v1:") {
+		t.Errorf("saved %q", data)
+	}
+	r := NewConfigReader(WithDecryptionKey(key))
+	r.Read(paths[0])
+	if got, _ := r.GetString("db_pass"); got != "newpw" {
+		t.Errorf("db_pass after reading again = %q", got)
+	}
+
+	// the key is needed to change an encrypted value
+	t.Setenv(DefaultDecryptionKeyEnv, "")
+	c = NewConfigReader()
+	c.ReadReader(strings.NewReader("a=1\n"), "test.ini")
+	c.lines = append(c.lines, configLine{text: "db_pass=" + value, key: "db_pass", valueStart: 8, valueEnd: 8 + len(value)})
+	if err := c.Set("db_pass", "plain"); !errors.Is(err, ErrNoDecryptionKey) {
+		t.Errorf("Set without key: %v", err)
+	}
+	if c.HasKey("db_pass") {
+		t.Error("refused Set changed the items")
+	}
+}
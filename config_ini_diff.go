@@ -0,0 +1,128 @@
+package goutils
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+/*
+
+config_ini_diff.go
+
+differences between two configurations, e.g. before a rollout:
+
+			current := goutils.NewConfigReader()
+			current.Read("/etc/myapp/app.ini")
+			next := goutils.NewConfigReader()
+			next.Read("release/app.ini")
+
+			diff := goutils.Diff(current, next)
+			fmt.Print(diff)                 // + added, - removed, ~ changed keys
+			data, _ := diff.JSON()
+
+or after every reload done by Watch:
+
+			conf.OnDiff(func(diff *goutils.ConfigDiff) {
+				log.Printf("config reloaded:\n%s", diff)
+			})
+
+the effective values are compared (environment overrides included), the values of
+secret keys are masked in both renderings
+
+*/
+
+// ConfigChange is a key added, removed or changed between two configurations
+type ConfigChange struct {
+	Key string `json:"key"`
+	Old string `json:"old"` // empty for an added key
+	New string `json:"new"` // empty for a removed key
+}
+
+// ConfigDiff lists the keys that differ between two configurations, sorted by key
+type ConfigDiff struct {
+	Added   []ConfigChange `json:"added"`
+	Removed []ConfigChange `json:"removed"`
+	Changed []ConfigChange `json:"changed"`
+}
+
+// Diff returns the keys added, removed and changed going from a to b, a nil reader has no keys
+func Diff(a, b *ConfigReader) *ConfigDiff {
+	d := &ConfigDiff{Added: []ConfigChange{}, Removed: []ConfigChange{}, Changed: []ConfigChange{}}
+	if a == nil {
+		a = NewConfigReader()
+	}
+	if b == nil {
+		b = NewConfigReader()
+	}
+	// each side is read from a single version of its configuration
+	a, b = a.Snapshot(), b.Snapshot()
+
+	keys := make(map[string]bool)
+	for k := range a.currentItems() {
+		keys[k] = true
+	}
+	for k := range b.currentItems() {
+		keys[k] = true
+	}
+	sorted := make([]string, 0, len(keys))
+	for k := range keys {
+		sorted = append(sorted, k)
+	}
+	sort.Strings(sorted)
+
+	for _, k := range sorted {
+		oldValue, inA := a.lookup(k)
+		newValue, inB := b.lookup(k)
+		secret := a.IsSecret(k) || b.IsSecret(k)
+		mask := func(v string) string {
+			if secret {
+				return maskedValue
+			}
+			return v
+		}
+		switch {
+		case !inA:
+			d.Added = append(d.Added, ConfigChange{Key: k, New: mask(newValue)})
+		case !inB:
+			d.Removed = append(d.Removed, ConfigChange{Key: k, Old: mask(oldValue)})
+		case oldValue != newValue:
+			d.Changed = append(d.Changed, ConfigChange{Key: k, Old: mask(oldValue), New: mask(newValue)})
+		}
+	}
+	return d
+}
+
+// Empty tells whether the two configurations have the same keys and values
+func (d *ConfigDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// String returns a line for each difference: '+ key = new', '- key = old', '~ key: old -> new'
+func (d *ConfigDiff) String() string {
+	var sb strings.Builder
+	for _, ch := range d.Added {
+		fmt.Fprintf(&sb, "+ %s = %s\n", ch.Key, ch.New)
+	}
+	for _, ch := range d.Removed {
+		fmt.Fprintf(&sb, "- %s = %s\n", ch.Key, ch.Old)
+	}
+	for _, ch := range d.Changed {
+		fmt.Fprintf(&sb, "~ %s: %s -> %s\n", ch.Key, ch.Old, ch.New)
+	}
+	return sb.String()
+}
+
+// JSON returns the differences as a JSON object with the 'added', 'removed' and 'changed' lists
+func (d *ConfigDiff) JSON() ([]byte, error) {
+	return json.MarshalIndent(d, "", "  ")
+}
+
+// OnDiff registers a function called by Watch after a reload changed some items,
+// with the differences from the previous configuration
+func (c *ConfigReader) OnDiff(fn func(diff *ConfigDiff)) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.onDiff = append(c.onDiff, fn)
+}
@@ -0,0 +1,319 @@
+package goutils
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+/*
+
+config_ini_dotenv.go
+
+ConfigReader reads .env files too, with the same accessors of the ini files:
+
+			# database
+			export DB_HOST=localhost
+			DB_PORT=5432                    # inline comment
+			DB_URL="postgres://${DB_HOST}:${DB_PORT:-5432}/app"
+			GREETING='no $expansion here'
+			CERT="-----BEGIN CERTIFICATE-----
+			MIIB...
+			-----END CERTIFICATE-----"
+
+			conf.ReadDotEnv(".env", ".env.local")   // later files override earlier ones
+			host, _ := conf.GetString("DB_HOST")
+			conf.ExportEnv()                        // os.Setenv of the variables not already set
+
+${VAR}, ${VAR:-default} and $VAR in unquoted and double quoted values are expanded with
+the values defined before in the files, then with the environment; single quoted values
+are taken as they are. Double quoted values understand \n \r \t \" \\ and \$
+
+*/
+
+// ReadDotEnv reads .env files in order, the values of later files override earlier ones.
+// Their lines are not kept, Save and SaveAs are not available
+func (c *ConfigReader) ReadDotEnv(envPaths ...string) (numItemsFound int, err error) {
+	if len(envPaths) == 0 {
+		return 0, errors.New("goutils.ConfigReader.ReadDotEnv: no .env file")
+	}
+	paths := append([]string(nil), envPaths...)
+	failed := &parsedConfig{path: paths[len(paths)-1], noLines: true}
+	for _, p := range paths {
+		failed.files = append(failed.files, watchedFile{path: p})
+	}
+	return c.load(failed, func() (*parsedConfig, error) {
+		l, err := c.newLoader(nil)
+		if err != nil {
+			return nil, err
+		}
+		l.expanded = true
+		l.pc.noLines = true
+		for _, p := range paths {
+			if err = l.readDotEnvFile(p); err != nil {
+				return nil, err
+			}
+		}
+		l.pc.path = paths[len(paths)-1]
+		return l.finish()
+	})
+}
+
+// ExportEnv sets an environment variable for each configured item, by qualified name,
+// leaving unchanged the variables already set. It returns the names of the variables set
+func (c *ConfigReader) ExportEnv() (exported []string, err error) {
+	items := c.currentItems()
+	names := make([]string, 0, len(items))
+	for k := range items {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if _, set := os.LookupEnv(name); set {
+			continue
+		}
+		if err = os.Setenv(name, items[name]); err != nil {
+			c.logf("goutils.ConfigReader.ExportEnv(%s) error: %s", name, err)
+			return exported, fmt.Errorf("goutils.ConfigReader.ExportEnv(%s): %v", name, err)
+		}
+		exported = append(exported, name)
+	}
+	return
+}
+
+// readDotEnvFile reads the variables of a .env file
+func (l *configLoader) readDotEnvFile(envPath string) (err error) {
+	file, err := os.Open(envPath)
+	if err != nil {
+		l.c.logf("goutils.ConfigReader.ReadDotEnv(%s) open error: %s", envPath, err)
+		return
+	}
+	defer file.Close()
+
+	var info os.FileInfo
+	if info, err = file.Stat(); err != nil {
+		l.c.logf("goutils.ConfigReader.ReadDotEnv(%s) stat error: %s", envPath, err)
+		return
+	}
+	l.pc.files = append(l.pc.files, watchedFile{path: envPath, info: info})
+
+	return l.readDotEnvLines(file, envPath)
+}
+
+// readDotEnvLines reads the variables of a .env content named envPath
+func (l *configLoader) readDotEnvLines(r io.Reader, envPath string) (err error) {
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		l.c.logf("goutils.ConfigReader.ReadDotEnv(%s) read error: %s", envPath, err)
+		return
+	}
+	text, _, err := l.decode(data, envPath)
+	if err != nil {
+		l.c.logf("goutils.ConfigReader.ReadDotEnv(%s) decode error: %s", envPath, err)
+		return
+	}
+
+	lineNumber := 0
+	seen := make(map[string]int) // line of each key, to report duplicates
+	lr := newLineReader(strings.NewReader(text), l.c.lineLimit())
+	for {
+		t, oversized, ok := lr.next()
+		if !ok {
+			break
+		}
+		lineNumber++
+		first := lineNumber
+		if oversized {
+			l.addProblem(envPath, first, l.c.lineLimit()+1, l.c.maskLine(t), fmt.Sprintf("line longer than %d bytes", l.c.lineLimit()))
+			continue
+		}
+
+		s := strings.TrimSpace(t)
+		if s == "" || s[0] == '#' {
+			continue
+		}
+		if strings.HasPrefix(s, "export ") || strings.HasPrefix(s, "export\t") {
+			s = strings.TrimSpace(s[len("export"):])
+		}
+		eq := strings.IndexByte(s, '=')
+		if eq < 0 {
+			l.addProblem(envPath, first, 1, l.c.maskLine(t), "missing '=' after the name")
+			continue
+		}
+		name := strings.TrimSpace(s[:eq])
+		if !isDotEnvName(name) {
+			l.addProblem(envPath, first, 1, l.c.maskLine(t), fmt.Sprintf("invalid name '%s'", name))
+			continue
+		}
+		raw := strings.TrimLeft(s[eq+1:], " \t")
+
+		// quoted values can continue on the following lines
+		quote := byte(0)
+		if raw != "" && (raw[0] == '"' || raw[0] == '\'') {
+			quote = raw[0]
+			for closingQuote(raw, quote) < 0 {
+				next, nextOversized, ok := lr.next()
+				if !ok || nextOversized {
+					break
+				}
+				lineNumber++
+				raw += "\n" + next
+			}
+		}
+
+		value, reason := l.dotEnvValue(raw, quote)
+		if reason != "" {
+			l.addProblem(envPath, first, eq+2, l.c.maskLine(t), reason)
+			continue
+		}
+		if previous, duplicate := seen[name]; duplicate {
+			l.addProblem(envPath, first, 1, l.c.maskLine(t), fmt.Sprintf("duplicate key '%s', first defined at line %d", name, previous))
+		}
+		seen[name] = first
+
+		l.define(name, value, itemOrigin{source: ConfigSourceFile, path: envPath, line: first})
+		l.c.logf("goutils.ConfigReader.ReadDotEnv(%s) line %d decoded key: '%v', value: '%v'", envPath, first, name, l.c.maskValue(name, value))
+	}
+
+	if err = lr.err; err != nil {
+		l.c.logf("goutils.ConfigReader.ReadDotEnv(%s) scan error: %s", envPath, err)
+	}
+	return
+}
+
+// dotEnvValue decodes the raw value of a variable, reason tells why it is invalid
+func (l *configLoader) dotEnvValue(raw string, quote byte) (value string, reason string) {
+	if quote == 0 {
+		// an inline comment starts with '#' after a blank
+		for i := 1; i < len(raw); i++ {
+			if raw[i] == '#' && (raw[i-1] == ' ' || raw[i-1] == '\t') {
+				raw = raw[:i]
+				break
+			}
+		}
+		return l.expandDotEnv(strings.TrimSpace(raw), false), ""
+	}
+
+	end := closingQuote(raw, quote)
+	if end < 0 {
+		return "", fmt.Sprintf("missing closing %c", quote)
+	}
+	if rest := strings.TrimSpace(raw[end+1:]); rest != "" && rest[0] != '#' {
+		return "", fmt.Sprintf("unexpected text after the closing %c", quote)
+	}
+	if quote == '\'' {
+		return raw[1:end], ""
+	}
+
+	return l.expandDotEnv(raw[1:end], true), ""
+}
+
+// expandDotEnv replaces ${VAR}, ${VAR:-default} and $VAR with the value of VAR,
+// escapes decodes the backslash sequences of double quoted values
+func (l *configLoader) expandDotEnv(s string, escapes bool) string {
+	if !strings.ContainsAny(s, "$\\") {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch {
+		case escapes && s[i] == '\\' && i+1 < len(s):
+			i++
+			switch s[i] {
+			case 'n':
+				b.WriteByte('\n')
+			case 'r':
+				b.WriteByte('\r')
+			case 't':
+				b.WriteByte('\t')
+			case '"', '\\', '$':
+				b.WriteByte(s[i])
+			default:
+				b.WriteByte('\\')
+				b.WriteByte(s[i])
+			}
+		case s[i] != '$' || i+1 == len(s):
+			b.WriteByte(s[i])
+		case s[i+1] == '{':
+			end := strings.IndexByte(s[i:], '}')
+			if end < 0 {
+				b.WriteString(s[i:])
+				return b.String()
+			}
+			ref := s[i+2 : i+end]
+			name, def, hasDefault := ref, "", false
+			if j := strings.Index(ref, ":-"); j >= 0 {
+				name, def, hasDefault = ref[:j], ref[j+2:], true
+			}
+			v, found := l.dotEnvLookup(name)
+			if (!found || v == "") && hasDefault {
+				v = def
+			}
+			b.WriteString(v)
+			i += end
+		default:
+			j := i + 1
+			for j < len(s) && isDotEnvNameByte(s[j], j == i+1) {
+				j++
+			}
+			if j == i+1 {
+				b.WriteByte('$')
+				continue
+			}
+			v, _ := l.dotEnvLookup(s[i+1 : j])
+			b.WriteString(v)
+			i = j - 1
+		}
+	}
+	return b.String()
+}
+
+// dotEnvLookup returns a variable defined before in the files, or in the environment
+func (l *configLoader) dotEnvLookup(name string) (string, bool) {
+	if v, ok := l.pc.items[name]; ok {
+		return v, true
+	}
+	return os.LookupEnv(name)
+}
+
+// closingQuote returns the position of the quote closing a value starting with quote, -1 if missing
+func closingQuote(raw string, quote byte) int {
+	for i := 1; i < len(raw); i++ {
+		if quote == '"' && raw[i] == '\\' {
+			i++
+			continue
+		}
+		if raw[i] == quote {
+			return i
+		}
+	}
+	return -1
+}
+
+// isDotEnvName tells if name is a valid variable name
+func isDotEnvName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i := 0; i < len(name); i++ {
+		if !isDotEnvNameByte(name[i], i == 0) && !(i > 0 && (name[i] == '.' || name[i] == '-')) {
+			return false
+		}
+	}
+	return true
+}
+
+// isDotEnvNameByte tells if b can be part of a variable name referenced by $VAR
+func isDotEnvNameByte(b byte, first bool) bool {
+	switch {
+	case b == '_', b >= 'A' && b <= 'Z', b >= 'a' && b <= 'z':
+		return true
+	case b >= '0' && b <= '9':
+		return !first
+	}
+	return false
+}
@@ -0,0 +1,40 @@
+package goutils
+
+import (
+	"os"
+	"testing"
+)
+
+func TestReadDotEnv(t *testing.T) {
+	t.Setenv(DefaultProfileEnv, "")
+	paths := writeConfigFiles(t, ".env", "# comment\nexport A=1\nB='x $A'\nC=\"${A}2\"\nD=\"multi\nline\"\n")
+	c := NewConfigReader()
+	if _, err := c.ReadDotEnv(paths[0]); err != nil {
+		t.Fatal(err)
+	}
+	for key, want := range map[string]string{"A": "1", "B": "x $A", "C": "12", "D": "multi\nline"} {
+		if got, _ := c.GetString(key); got != want {
+			t.Errorf("%s = %q, want %q", key, got, want)
+		}
+	}
+}
+
+func TestDotEnvNotSaved(t *testing.T) {
+	t.Setenv(DefaultProfileEnv, "")
+	paths := writeConfigFiles(t, ".env", "A=1\nB=2\n")
+	c := NewConfigReader()
+	c.ReadDotEnv(paths[0])
+	c.Set("C", "3")
+	if err := c.SaveAs(paths[0]); err == nil {
+		t.Error("SaveAs after ReadDotEnv did not fail")
+	}
+	if err := c.Save(); err == nil {
+		t.Error("Save after ReadDotEnv did not fail")
+	}
+	if data, _ := os.ReadFile(paths[0]); string(data) != "A=1\nB=2\n" {
+		t.Errorf("file changed: %q", data)
+	}
+	if s := c.Snapshot(); !s.noLines {
+		t.Error("snapshot lost noLines")
+	}
+}
@@ -0,0 +1,264 @@
+package goutils
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+/*
+
+config_ini_encoding.go
+
+files written by Windows editors are read as they are meant:
+
+	- a UTF-8 byte order mark is dropped instead of being glued to the first key
+	- CRLF line ends (and the CR ones of old Mac files) are read as plain line ends
+	- UTF-16LE and UTF-16BE files, with or without byte order mark, are decoded
+
+files that are not valid UTF-8 are read as they are, with a warning; when they come
+from a legacy editor they can be decoded from Windows-1252 or ISO-8859-1:
+
+			conf := goutils.NewConfigReader(goutils.WithFallbackEncoding(goutils.EncodingWindows1252))
+			conf.Read("C:\\myapp\\app.ini")   // descrizione=perché è così
+			conf.FileFormat()                 // {windows-1252 false \r\n}
+
+Save and MigrateFile write the file back with its encoding, byte order mark and line ends
+
+*/
+
+// ConfigEncoding is the character encoding of a config file
+type ConfigEncoding string
+
+// encodings of the config files
+const (
+	EncodingUTF8        ConfigEncoding = "utf-8"
+	EncodingUTF16LE     ConfigEncoding = "utf-16le"
+	EncodingUTF16BE     ConfigEncoding = "utf-16be"
+	EncodingWindows1252 ConfigEncoding = "windows-1252"
+	EncodingISO88591    ConfigEncoding = "iso-8859-1"
+)
+
+// ConfigFileFormat is how a config file is written, kept to write it back the same way
+type ConfigFileFormat struct {
+	Encoding ConfigEncoding
+	BOM      bool   // the file starts with a byte order mark
+	Newline  string // "\n", "\r\n" or "\r"
+}
+
+var (
+	bomUTF8    = []byte{0xEF, 0xBB, 0xBF}
+	bomUTF16LE = []byte{0xFF, 0xFE}
+	bomUTF16BE = []byte{0xFE, 0xFF}
+)
+
+// windows1252 maps the bytes 0x80-0x9F of Windows-1252, the others are the same as ISO-8859-1.
+// The five bytes not defined are kept as the control characters of ISO-8859-1
+var windows1252 = [32]rune{
+	'€', 0x81, '‚', 'ƒ', '„', '…', '†', '‡', 'ˆ', '‰', 'Š', '‹', 'Œ', 0x8D, 'Ž', 0x8F,
+	0x90, '‘', '’', '“', '”', '•', '–', '—', '˜', '™', 'š', '›', 'œ', 0x9D, 'ž', 'Ÿ',
+}
+
+// WithFallbackEncoding decodes the files that are not valid UTF-8 and have no byte order mark
+// from encoding, EncodingWindows1252 or EncodingISO88591
+func WithFallbackEncoding(encoding ConfigEncoding) ConfigOption {
+	return func(c *ConfigReader) {
+		c.fallbackEncoding = encoding
+	}
+}
+
+// FileFormat returns the encoding, byte order mark and line ends of the file read, used by Save
+func (c *ConfigReader) FileFormat() ConfigFileFormat {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.format.normalized()
+}
+
+// normalized fills the fields of a zero format: UTF-8 with '\n' line ends
+func (f ConfigFileFormat) normalized() ConfigFileFormat {
+	if f.Encoding == "" {
+		f.Encoding = EncodingUTF8
+	}
+	if f.Newline == "" {
+		f.Newline = "\n"
+	}
+	return f
+}
+
+// decodeConfig returns the text of a config file as UTF-8 with '\n' line ends, and its format.
+// A text not valid UTF-8 is returned as it is when fallback is empty
+func decodeConfig(data []byte, fallback ConfigEncoding) (text string, f ConfigFileFormat, err error) {
+	f.Encoding = EncodingUTF8
+	switch {
+	case bytes.HasPrefix(data, bomUTF8):
+		f.BOM = true
+		data = data[len(bomUTF8):]
+	case bytes.HasPrefix(data, bomUTF16LE):
+		f.Encoding, f.BOM = EncodingUTF16LE, true
+		data = data[len(bomUTF16LE):]
+	case bytes.HasPrefix(data, bomUTF16BE):
+		f.Encoding, f.BOM = EncodingUTF16BE, true
+		data = data[len(bomUTF16BE):]
+	case len(data) >= 2 && data[0] != 0 && data[1] == 0:
+		// an ini file starts with an ASCII character, never with a NUL
+		f.Encoding = EncodingUTF16LE
+	case len(data) >= 2 && data[0] == 0 && data[1] != 0:
+		f.Encoding = EncodingUTF16BE
+	case fallback != "" && fallback != EncodingUTF8 && !utf8.Valid(data):
+		f.Encoding = fallback
+	}
+
+	switch f.Encoding {
+	case EncodingUTF8:
+		text = string(data)
+	case EncodingUTF16LE, EncodingUTF16BE:
+		text = decodeUTF16(data, f.Encoding == EncodingUTF16BE)
+	case EncodingWindows1252, EncodingISO88591:
+		text = decodeSingleByte(data, f.Encoding == EncodingWindows1252)
+	default:
+		return "", f, fmt.Errorf("unsupported encoding '%s'", f.Encoding)
+	}
+
+	f.Newline = "\n"
+	if i := strings.IndexAny(text, "\r\n"); i >= 0 && text[i] == '\r' {
+		if strings.HasPrefix(text[i:], "\r\n") {
+			f.Newline = "\r\n"
+		} else {
+			f.Newline = "\r"
+		}
+	}
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	if f.Newline == "\r" {
+		text = strings.ReplaceAll(text, "\r", "\n")
+	}
+	// a CR left at the end of the last line
+	text = strings.TrimSuffix(text, "\r")
+	return
+}
+
+// decodeUTF16 converts UTF-16 to UTF-8, an odd last byte becomes U+FFFD
+func decodeUTF16(data []byte, bigEndian bool) string {
+	units := make([]uint16, len(data)/2)
+	for i := range units {
+		if bigEndian {
+			units[i] = uint16(data[2*i])<<8 | uint16(data[2*i+1])
+		} else {
+			units[i] = uint16(data[2*i+1])<<8 | uint16(data[2*i])
+		}
+	}
+	s := string(utf16.Decode(units))
+	if len(data)%2 != 0 {
+		s += string(utf8.RuneError)
+	}
+	return s
+}
+
+// decodeSingleByte converts ISO-8859-1, or Windows-1252 when cp1252 is true, to UTF-8
+func decodeSingleByte(data []byte, cp1252 bool) string {
+	var sb strings.Builder
+	sb.Grow(len(data) + len(data)/8)
+	for _, b := range data {
+		if cp1252 && b >= 0x80 && b <= 0x9F {
+			sb.WriteRune(windows1252[b-0x80])
+		} else {
+			sb.WriteRune(rune(b))
+		}
+	}
+	return sb.String()
+}
+
+// encodeConfig converts the text of a config file, with '\n' line ends, to the format f
+func encodeConfig(text string, f ConfigFileFormat) ([]byte, error) {
+	f = f.normalized()
+	if f.Newline != "\n" {
+		text = strings.ReplaceAll(text, "\n", f.Newline)
+	}
+
+	var b bytes.Buffer
+	switch f.Encoding {
+	case EncodingUTF8:
+		if f.BOM {
+			b.Write(bomUTF8)
+		}
+		b.WriteString(text)
+	case EncodingUTF16LE, EncodingUTF16BE:
+		bigEndian := f.Encoding == EncodingUTF16BE
+		units := utf16.Encode([]rune(text))
+		if f.BOM {
+			units = append([]uint16{0xFEFF}, units...)
+		}
+		for _, u := range units {
+			if bigEndian {
+				b.WriteByte(byte(u >> 8))
+				b.WriteByte(byte(u))
+			} else {
+				b.WriteByte(byte(u))
+				b.WriteByte(byte(u >> 8))
+			}
+		}
+	case EncodingWindows1252, EncodingISO88591:
+		cp1252 := f.Encoding == EncodingWindows1252
+		for _, r := range text {
+			c, ok := encodeSingleByte(r, cp1252)
+			if !ok {
+				return nil, fmt.Errorf("character '%c' cannot be written in %s", r, f.Encoding)
+			}
+			b.WriteByte(c)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported encoding '%s'", f.Encoding)
+	}
+	return b.Bytes(), nil
+}
+
+// encodeSingleByte returns the ISO-8859-1 byte of r, or the Windows-1252 one when cp1252 is true
+func encodeSingleByte(r rune, cp1252 bool) (byte, bool) {
+	if cp1252 {
+		for i, w := range windows1252 {
+			if w == r {
+				return byte(0x80 + i), true
+			}
+		}
+		if r >= 0x80 && r <= 0x9F {
+			return 0, false
+		}
+	}
+	if r < 0x100 {
+		return byte(r), true
+	}
+	return 0, false
+}
+
+// decode reads the whole content of a config file as UTF-8 text, a text not valid UTF-8
+// is reported as a problem of the first line with an invalid sequence
+func (l *configLoader) decode(data []byte, configPath string) (text string, f ConfigFileFormat, err error) {
+	if text, f, err = decodeConfig(data, l.c.fallbackEncoding); err != nil {
+		return
+	}
+	if f.Encoding != EncodingUTF8 || f.BOM {
+		l.c.logf("goutils.ConfigReader.Read(%s) decoded from %s, byte order mark: %v", configPath, f.Encoding, f.BOM)
+	}
+	if f.Encoding == EncodingUTF8 && !utf8.ValidString(text) {
+		for i, line := range strings.Split(text, "\n") {
+			if column := invalidUTF8Column(line); column > 0 {
+				l.addProblem(configPath, i+1, column, l.c.maskLine(strings.ToValidUTF8(line, "\uFFFD")), "invalid UTF-8, see WithFallbackEncoding")
+				break
+			}
+		}
+	}
+	return
+}
+
+// invalidUTF8Column returns the 1 based position of the first invalid UTF-8 byte of s, 0 when valid
+func invalidUTF8Column(s string) int {
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			return i + 1
+		}
+		i += size
+	}
+	return 0
+}
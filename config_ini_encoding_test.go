@@ -0,0 +1,94 @@
+package goutils
+
+import (
+	"os"
+	"testing"
+	"unicode/utf16"
+)
+
+// encodeUTF16 returns s in UTF-16, with the byte order mark when bom
+func encodeUTF16(s string, bigEndian, bom bool) []byte {
+	units := utf16.Encode([]rune(s))
+	if bom {
+		units = append([]uint16{0xFEFF}, units...)
+	}
+	var b []byte
+	for _, u := range units {
+		if bigEndian {
+			b = append(b, byte(u>>8), byte(u))
+		} else {
+			b = append(b, byte(u), byte(u>>8))
+		}
+	}
+	return b
+}
+
+func TestEncodings(t *testing.T) {
+	t.Setenv(DefaultProfileEnv, "")
+	text := "name=città\r\n[db]\r\nhost=perché\r\n"
+	tests := []struct {
+		name     string
+		data     []byte
+		fallback ConfigEncoding
+		want     ConfigFileFormat
+	}{
+		{"bom", append([]byte{0xEF, 0xBB, 0xBF}, text...), "", ConfigFileFormat{EncodingUTF8, true, "\r\n"}},
+		{"crlf", []byte(text), "", ConfigFileFormat{EncodingUTF8, false, "\r\n"}},
+		{"cr", []byte("name=città\r[db]\rhost=perché\r"), "", ConfigFileFormat{EncodingUTF8, false, "\r"}},
+		{"utf16le-bom", encodeUTF16(text, false, true), "", ConfigFileFormat{EncodingUTF16LE, true, "\r\n"}},
+		{"utf16le", encodeUTF16(text, false, false), "", ConfigFileFormat{EncodingUTF16LE, false, "\r\n"}},
+		{"utf16be-bom", encodeUTF16(text, true, true), "", ConfigFileFormat{EncodingUTF16BE, true, "\r\n"}},
+		{"utf16be", encodeUTF16(text, true, false), "", ConfigFileFormat{EncodingUTF16BE, false, "\r\n"}},
+		{"windows-1252", []byte("name=citt\xe0\r\n[db]\r\nhost=perch\xe9\r\n"), EncodingWindows1252, ConfigFileFormat{EncodingWindows1252, false, "\r\n"}},
+		{"iso-8859-1", []byte("name=citt\xe0\n[db]\nhost=perch\xe9\n"), EncodingISO88591, ConfigFileFormat{EncodingISO88591, false, "\n"}},
+	}
+	for _, tt := range tests {
+		paths := writeConfigFiles(t, tt.name+".ini", string(tt.data))
+		c := NewConfigReader(WithFallbackEncoding(tt.fallback))
+		if _, err := c.Read(paths[0]); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		name, _ := c.GetString("name")
+		host, _ := c.GetString("db.host")
+		if name != "città" || host != "perché" {
+			t.Errorf("%s: name %q host %q", tt.name, name, host)
+		}
+		if got := c.FileFormat(); got != tt.want {
+			t.Errorf("%s: FileFormat() = %+v, want %+v", tt.name, got, tt.want)
+		}
+		if len(c.Warnings()) != 0 {
+			t.Errorf("%s: warnings %v", tt.name, c.Warnings())
+		}
+
+		// written back in the same format
+		c.Set("db.host", "è")
+		if err := c.Save(); err != nil {
+			t.Fatalf("%s: Save: %v", tt.name, err)
+		}
+		r := NewConfigReader(WithFallbackEncoding(tt.fallback))
+		r.Read(paths[0])
+		if got, _ := r.GetString("db.host"); got != "è" || r.FileFormat() != tt.want {
+			t.Errorf("%s: after Save host %q format %+v", tt.name, got, r.FileFormat())
+		}
+	}
+}
+
+func TestInvalidUTF8(t *testing.T) {
+	t.Setenv(DefaultProfileEnv, "")
+	paths := writeConfigFiles(t, "latin1.ini", "a=1\nname=citt\xe0\n")
+	c := NewConfigReader()
+	c.Read(paths[0])
+	if w := c.Warnings(); len(w) != 1 || w[0].Line != 2 || w[0].Column != 10 {
+		t.Errorf("Warnings() = %v", w)
+	}
+
+	c = NewConfigReader(WithFallbackEncoding(EncodingISO88591))
+	c.Read(paths[0])
+	c.Set("name", "€")
+	if err := c.Save(); err == nil {
+		t.Error("Save of '€' in ISO-8859-1 did not fail")
+	}
+	if data, _ := os.ReadFile(paths[0]); string(data) != "a=1\nname=citt\xe0\n" {
+		t.Errorf("file changed: %q", data)
+	}
+}
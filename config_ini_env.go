@@ -0,0 +1,79 @@
+package goutils
+
+import (
+	"os"
+	"strings"
+)
+
+// ConfigSource tells where the value of a configured item comes from
+type ConfigSource string
+
+// Sources of a configured item value
+const (
+	ConfigSourceNone    ConfigSource = ""
+	ConfigSourceFile    ConfigSource = "file"
+	ConfigSourceEnv     ConfigSource = "env"
+	ConfigSourceDefault ConfigSource = "default"
+	ConfigSourceSet     ConfigSource = "set" // ConfigReader.Set
+)
+
+// WithEnvPrefix makes environment variables override the values read from file.
+// With prefix "MYAPP_" the key 'db_port' is overridden by MYAPP_DB_PORT and the key
+// 'port' of section [database] by MYAPP_DATABASE_PORT
+func WithEnvPrefix(prefix string) ConfigOption {
+	return func(c *ConfigReader) {
+		c.envPrefix = prefix
+	}
+}
+
+// EnvName returns the environment variable overriding an item,
+// or an empty string when no env prefix is configured
+func (c *ConfigReader) EnvName(itemName string) string {
+	if c.envPrefix == "" {
+		return ""
+	}
+	return envVarName(c.envPrefix, itemName)
+}
+
+// envVarName returns the environment variable name of an item: prefix followed by the
+// item name in upper case, with every character other than A-Z, 0-9 and '_' replaced by '_'
+func envVarName(prefix, itemName string) string {
+	name := strings.Map(func(r rune) rune {
+		if !(r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_') {
+			return '_'
+		}
+		return r
+	}, strings.ToUpper(itemName))
+	return prefix + name
+}
+
+// Source returns where the effective value of an item comes from,
+// ConfigSourceNone when the item is not configured
+func (c *ConfigReader) Source(itemName string) ConfigSource {
+	_, source, _ := c.lookupSource(itemName)
+	return source
+}
+
+// lookupEnv returns the value of the environment variable overriding an item, decrypted when
+// it is an enc:v1: value; a value that cannot be decrypted is logged and ignored (Read fails
+// on it when the item is in the files)
+func (c *ConfigReader) lookupEnv(itemName string) (string, bool) {
+	v, ok := c.lookupRawEnv(itemName)
+	if !ok || !IsEncryptedValue(v) {
+		return v, ok
+	}
+	plaintext, err := c.decryptEnv(itemName, v)
+	if err != nil {
+		c.logf("goutils.ConfigReader: %s", err)
+		return "", false
+	}
+	return plaintext, true
+}
+
+// lookupRawEnv returns the value of the environment variable overriding an item, as it is
+func (c *ConfigReader) lookupRawEnv(itemName string) (string, bool) {
+	if c.envPrefix == "" {
+		return "", false
+	}
+	return os.LookupEnv(c.EnvName(itemName))
+}
@@ -0,0 +1,130 @@
+package goutils
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+/*
+
+config_ini_errors.go
+
+problems found while parsing config files: malformed lines, duplicate keys and
+lines longer than the maximum length.
+
+by default they are collected as warnings and the file is read anyway:
+
+			conf.Read("config.ini")
+			for _, w := range conf.Warnings() {
+				log.Printf("config warning: %v", w)
+			}
+
+in strict mode Read fails returning them as ParseErrors:
+
+			conf := goutils.NewConfigReader(goutils.WithStrictParsing(true))
+			_, err := conf.Read("config.ini")
+			if perrs, ok := err.(goutils.ParseErrors); ok { ... }
+
+*/
+
+// defaultMaxLineLength is the longest line accepted when WithMaxLineLength is not used
+const defaultMaxLineLength = bufio.MaxScanTokenSize
+
+// ParseError is a problem found in a line of a config file
+type ParseError struct {
+	File   string
+	Line   int    // 1 based
+	Column int    // 1 based, 0 when unknown
+	Text   string // the line, truncated when too long
+	Reason string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("%s:%d:%d: %s: '%s'", e.File, e.Line, e.Column, e.Reason, e.Text)
+}
+
+// ParseErrors is the error returned by Read in strict mode listing every problem found
+type ParseErrors []*ParseError
+
+func (e ParseErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, pe := range e {
+		msgs[i] = pe.Error()
+	}
+	return fmt.Sprintf("goutils.ConfigReader.Read: %d parse errors: %s", len(e), strings.Join(msgs, "; "))
+}
+
+// WithStrictParsing makes Read fail with ParseErrors when the files have malformed lines,
+// duplicate keys or lines too long; otherwise they are returned by Warnings
+func WithStrictParsing(strict bool) ConfigOption {
+	return func(c *ConfigReader) {
+		c.strict = strict
+	}
+}
+
+// WithMaxLineLength sets the longest line accepted in bytes, 64KB by default
+func WithMaxLineLength(n int) ConfigOption {
+	return func(c *ConfigReader) {
+		c.maxLineLength = n
+	}
+}
+
+// Warnings returns the problems found by the last read in lenient mode
+func (c *ConfigReader) Warnings() []*ParseError {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return append([]*ParseError(nil), c.warnings...)
+}
+
+// lineLimit returns the longest line accepted
+func (c *ConfigReader) lineLimit() int {
+	if c.maxLineLength > 0 {
+		return c.maxLineLength
+	}
+	return defaultMaxLineLength
+}
+
+// addProblem records a problem found in a line
+func (l *configLoader) addProblem(configPath string, line, column int, text, reason string) {
+	if len(text) > 80 {
+		text = text[:80] + "..."
+	}
+	l.pc.warnings = append(l.pc.warnings, &ParseError{File: configPath, Line: line, Column: column, Text: text, Reason: reason})
+}
+
+// lineReader returns the lines of a file like bufio.Scanner does, but a line
+// longer than max is reported as oversized instead of stopping the read
+type lineReader struct {
+	r   *bufio.Reader
+	max int
+	err error // read error other than io.EOF
+}
+
+func newLineReader(r io.Reader, max int) *lineReader {
+	return &lineReader{r: bufio.NewReader(r), max: max}
+}
+
+// next returns the next line without the end of line, ok is false at the end of the file.
+// The text of an oversized line is truncated to max bytes
+func (lr *lineReader) next() (line string, oversized bool, ok bool) {
+	var buf []byte
+	for {
+		chunk, isPrefix, err := lr.r.ReadLine()
+		if err != nil {
+			if err != io.EOF {
+				lr.err = err
+			}
+			return
+		}
+		if len(buf)+len(chunk) > lr.max {
+			oversized = true
+			chunk = chunk[:lr.max-len(buf)]
+		}
+		buf = append(buf, chunk...)
+		if !isPrefix {
+			return string(buf), oversized, true
+		}
+	}
+}
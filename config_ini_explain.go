@@ -0,0 +1,194 @@
+package goutils
+
+import (
+	"fmt"
+	"strings"
+	"text/tabwriter"
+)
+
+/*
+
+config_ini_explain.go
+
+every value keeps where it comes from, Explain lists all the values given to a key
+from the lowest priority to the one in effect:
+
+			fmt.Print(conf.Explain("database.port"))
+
+			database.port = 6543
+			  default  defaults                    5432
+			  file     /etc/myapp/base.ini:12      5433
+			  file     /etc/myapp/site.ini:3       5434  @production
+			  env      MYAPP_DATABASE_PORT         6543  (in effect)
+
+the sources are the defaults, the files (with includes and duplicated keys), the
+profiles, the environment overrides and Set; PrintItems shows the source of each value
+and the values it overrides
+
+*/
+
+// ConfigValueOrigin is one of the values given to a key and where it comes from
+type ConfigValueOrigin struct {
+	Source   ConfigSource
+	File     string // file, 'defaults' or the name given to ReadReader; empty for env and set
+	Line     int    // 0 when unknown
+	EnvVar   string // environment variable of an env override
+	Profile  string // profile that supplied the value
+	Alias    string // deprecated name the value was read with
+	Value    string // as written, the effective value when InEffect; masked for secret keys
+	InEffect bool
+}
+
+// ConfigExplanation is the chain of values given to a key, lowest priority first
+type ConfigExplanation struct {
+	Key   string
+	Value string // effective value, masked for secret keys
+	Found bool
+	Chain []ConfigValueOrigin
+}
+
+// String describes where a value comes from: 'file /etc/app.ini:3', 'env NAME', 'default', 'set'
+func (o ConfigValueOrigin) String() string {
+	s := string(o.Source)
+	switch {
+	case o.EnvVar != "":
+		s += " " + o.EnvVar
+	case o.File != "" && o.Line > 0:
+		s += fmt.Sprintf(" %s:%d", o.File, o.Line)
+	case o.File != "":
+		s += " " + o.File
+	}
+	if o.Profile != "" {
+		s += " @" + o.Profile
+	}
+	if o.Alias != "" {
+		s += " as " + o.Alias
+	}
+	return s
+}
+
+// String formats the explanation, one line for each value of the chain
+func (e *ConfigExplanation) String() string {
+	var sb strings.Builder
+	if !e.Found {
+		fmt.Fprintf(&sb, "%s is not configured\n", e.Key)
+	} else {
+		fmt.Fprintf(&sb, "%s = %s\n", e.Key, e.Value)
+	}
+	w := tabwriter.NewWriter(&sb, 0, 4, 2, ' ', 0)
+	for _, o := range e.Chain {
+		where := strings.TrimPrefix(o.String(), string(o.Source))
+		state := ""
+		if o.InEffect {
+			state = "(in effect)"
+		}
+		fmt.Fprintf(w, "  %s\t%s\t%s\t%s\n", o.Source, strings.TrimSpace(where), o.Value, state)
+	}
+	w.Flush()
+	return sb.String()
+}
+
+// Explain returns every value given to an item and where it comes from, the last one of
+// the chain is the one in effect
+func (c *ConfigReader) Explain(itemName string) *ConfigExplanation {
+	snap := c.Snapshot()
+	e := &ConfigExplanation{Key: itemName}
+	value, source, found := snap.lookupSource(itemName)
+	e.Found = found
+
+	name, alias := itemName, ""
+	history := snap.history[itemName]
+	if _, configured := snap.currentItems()[itemName]; !configured {
+		// read through a deprecated name
+		for _, a := range snap.aliases[itemName] {
+			if _, configured := snap.currentItems()[a.oldName]; configured {
+				name, alias, history = a.oldName, a.oldName, snap.history[a.oldName]
+				break
+			}
+		}
+	}
+	if len(history) == 0 {
+		if o, ok := snap.origins[name]; ok {
+			history = []itemOrigin{o}
+		}
+	}
+	for _, h := range history {
+		e.Chain = append(e.Chain, ConfigValueOrigin{
+			Source:  h.source,
+			File:    h.path,
+			Line:    h.line,
+			Profile: h.profile,
+			Alias:   alias,
+			Value:   snap.maskValue(name, h.value),
+		})
+	}
+	if envValue, set := snap.lookupEnv(itemName); set {
+		e.Chain = append(e.Chain, ConfigValueOrigin{Source: ConfigSourceEnv, EnvVar: snap.EnvName(itemName), Value: snap.maskValue(itemName, envValue)})
+	} else if envValue, set := snap.lookupEnv(name); set && source == ConfigSourceEnv {
+		e.Chain = append(e.Chain, ConfigValueOrigin{Source: ConfigSourceEnv, EnvVar: snap.EnvName(name), Alias: alias, Value: snap.maskValue(name, envValue)})
+	}
+
+	if found {
+		e.Value = snap.maskValue(itemName, value)
+		if len(e.Chain) == 0 {
+			e.Chain = append(e.Chain, ConfigValueOrigin{Source: source})
+		}
+		last := &e.Chain[len(e.Chain)-1]
+		last.Value, last.InEffect = e.Value, true
+	}
+	return e
+}
+
+// define sets an item read by the loader, adding it to the history; the items of a profile
+// are held back until the end of their layer
+func (l *configLoader) define(itemName, value string, o itemOrigin) {
+	if l.deferProfile(itemName, value, o) {
+		return
+	}
+	l.setItem(itemName, value, o)
+}
+
+// setItem sets an item, adding it to the history
+func (l *configLoader) setItem(itemName, value string, o itemOrigin) {
+	o.value = value
+	l.pc.items[itemName] = value
+	l.pc.origins[itemName] = o
+	l.pc.history[itemName] = append(l.pc.history[itemName], o)
+}
+
+// recordSet records a value given by Set on the line of profile, c.lock must be held
+func (c *ConfigReader) recordSet(itemName, value, profile string, encrypted bool) {
+	o := itemOrigin{source: ConfigSourceSet, value: value, profile: profile, encrypted: encrypted}
+	origins := make(map[string]itemOrigin, len(c.origins)+1)
+	for k, v := range c.origins {
+		origins[k] = v
+	}
+	origins[itemName] = o
+	c.origins = origins
+
+	history := make(map[string][]itemOrigin, len(c.history)+1)
+	for k, v := range c.history {
+		history[k] = v
+	}
+	history[itemName] = append(append([]itemOrigin(nil), c.history[itemName]...), o)
+	c.history = history
+}
+
+// forget removes the origin and history of an item deleted, c.lock must be held
+func (c *ConfigReader) forget(itemName string) {
+	origins := make(map[string]itemOrigin, len(c.origins))
+	for k, v := range c.origins {
+		if k != itemName {
+			origins[k] = v
+		}
+	}
+	c.origins = origins
+
+	history := make(map[string][]itemOrigin, len(c.history))
+	for k, v := range c.history {
+		if k != itemName {
+			history[k] = v
+		}
+	}
+	c.history = history
+}
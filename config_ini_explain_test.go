@@ -0,0 +1,50 @@
+package goutils
+
+import (
+	"testing"
+)
+
+func TestExplain(t *testing.T) {
+	paths := writeConfigFiles(t,
+		"base.ini", "[database]\nport=5433\n",
+		"site.ini", "[database]\nport=5434\nport@production=5435\nhost=h\n")
+	c := NewConfigReader(WithDefaults(map[string]string{"database.port": "5432"}), WithProfile("production"), WithEnvPrefix("GOUTILS_TEST_"))
+	if _, err := c.ReadLayered(paths...); err != nil {
+		t.Fatal(err)
+	}
+
+	e := c.Explain("database.port")
+	want := []struct {
+		source ConfigSource
+		line   int
+		value  string
+	}{{ConfigSourceDefault, 0, "5432"}, {ConfigSourceFile, 2, "5433"}, {ConfigSourceFile, 2, "5434"}, {ConfigSourceFile, 3, "5435"}}
+	if !e.Found || e.Value != "5435" || len(e.Chain) != len(want) {
+		t.Fatalf("Explain = %s", e)
+	}
+	for i, w := range want {
+		o := e.Chain[i]
+		if o.Source != w.source || o.Line != w.line || o.Value != w.value || o.InEffect != (i == len(want)-1) {
+			t.Errorf("chain %d = %+v", i, o)
+		}
+	}
+	if e.Chain[3].Profile != "production" {
+		t.Errorf("profile = %q", e.Chain[3].Profile)
+	}
+
+	t.Setenv("GOUTILS_TEST_DATABASE_PORT", "6543")
+	e = c.Explain("database.port")
+	if last := e.Chain[len(e.Chain)-1]; last.Source != ConfigSourceEnv || last.EnvVar != "GOUTILS_TEST_DATABASE_PORT" || !last.InEffect || e.Value != "6543" {
+		t.Errorf("Explain with env = %s", e)
+	}
+
+	c.Set("database.host", "h2")
+	e = c.Explain("database.host")
+	if len(e.Chain) != 2 || e.Chain[1].Source != ConfigSourceSet || e.Value != "h2" {
+		t.Errorf("Explain after Set = %s", e)
+	}
+	c.Delete("database.host")
+	if e = c.Explain("database.host"); e.Found || len(e.Chain) != 0 {
+		t.Errorf("Explain after Delete = %s", e)
+	}
+}
@@ -0,0 +1,137 @@
+package goutils
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+/*
+
+config_ini_export.go
+
+the effective configuration (environment overrides included) exported in key order,
+so that the same configuration always gives the same output:
+
+			values := conf.ToMap()               // copy of the values
+			data, _ := conf.ToJSON(true)         // {"database.host": "db1", "database.password": "******"}
+			text := conf.ToEnv(true)             // DATABASE_HOST=db1
+			err := conf.WriteINI(w, true)        // keys outside sections, then [section] blocks
+
+with maskSecrets the values of secret keys are replaced by '******', as in PrintItems.
+ToEnv names follow EnvName (with the env prefix when configured) and quotes the values
+that are not shell safe: 'it'\''s'
+
+*/
+
+// ToMap returns a copy of the effective value of every item
+func (c *ConfigReader) ToMap() map[string]string {
+	snap := c.Snapshot()
+	items := snap.currentItems()
+	values := make(map[string]string, len(items))
+	for k := range items {
+		values[k], _ = snap.lookup(k)
+	}
+	return values
+}
+
+// ToJSON returns the items as a JSON object with the keys sorted
+func (c *ConfigReader) ToJSON(maskSecrets bool) ([]byte, error) {
+	// encoding/json writes the keys of a map sorted
+	data, err := json.MarshalIndent(c.exportValues(maskSecrets), "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("goutils.ConfigReader.ToJSON: %v", err)
+	}
+	return data, nil
+}
+
+// ToEnv returns the items as 'NAME=value' lines sorted by name, ready for a shell or a .env file
+func (c *ConfigReader) ToEnv(maskSecrets bool) string {
+	snap := c.Snapshot()
+	values := snap.exportValues(maskSecrets)
+	lines := make([]string, 0, len(values))
+	for k, v := range values {
+		lines = append(lines, envVarName(snap.envPrefix, k)+"="+shellQuote(v))
+	}
+	sort.Strings(lines)
+	if len(lines) == 0 {
+		return ""
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// WriteINI writes the items in ini syntax, the keys outside sections first,
+// then a block for each section, all sorted by name. With WithInterpolation(true) a '$'
+// is written as '$$', so that the output read again gives the same values. In the legacy
+// syntax a value holding a line end cannot be written and nothing is written
+func (c *ConfigReader) WriteINI(w io.Writer, maskSecrets bool) error {
+	snap := c.Snapshot()
+	values := snap.exportValues(maskSecrets)
+	bySection := make(map[string][]string)
+	for k, v := range values {
+		if snap.parserMode != ParserModeStandard && strings.ContainsAny(v, "\n\r") {
+			return fmt.Errorf("goutils.ConfigReader.WriteINI: the value of '%s' holds a line end, not allowed in the legacy syntax", k)
+		}
+		section, _ := snap.splitItemName(k)
+		bySection[section] = append(bySection[section], k)
+	}
+	sections := make([]string, 0, len(bySection))
+	for s := range bySection {
+		sections = append(sections, s)
+	}
+	sort.Strings(sections) // "" first
+
+	bw := bufio.NewWriter(w)
+	for i, s := range sections {
+		if s != "" {
+			if i > 0 {
+				bw.WriteString("\n")
+			}
+			fmt.Fprintf(bw, "[%s]\n", s)
+		}
+		keys := bySection[s]
+		sort.Strings(keys)
+		for _, k := range keys {
+			_, key := snap.splitItemName(k)
+			v := values[k]
+			if snap.interpolation {
+				v = strings.ReplaceAll(v, "$", "$$")
+			}
+			fmt.Fprintf(bw, "%s=%s\n", key, snap.formatValue(v))
+		}
+	}
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("goutils.ConfigReader.WriteINI: %v", err)
+	}
+	return nil
+}
+
+// exportValues returns the effective values, masked when maskSecrets
+func (c *ConfigReader) exportValues(maskSecrets bool) map[string]string {
+	values := c.ToMap()
+	if maskSecrets {
+		for k, v := range values {
+			values[k] = c.maskValue(k, v)
+		}
+	}
+	return values
+}
+
+// shellQuote returns s as a shell word, single quoted unless made only of safe characters
+func shellQuote(s string) string {
+	if s == "" {
+		return "''"
+	}
+	safe := true
+	for i := 0; i < len(s) && safe; i++ {
+		b := s[i]
+		safe = b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z' || b >= '0' && b <= '9' || strings.IndexByte("_-./:@%+,=", b) >= 0
+	}
+	if safe {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,73 @@
+package goutils
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWriteINIRoundTrip(t *testing.T) {
+	t.Setenv(DefaultProfileEnv, "")
+	key := make([]byte, 32)
+	secret, _ := EncryptConfigValue(key, "p$ss")
+	text := "a=$${x}\nb=${a}!\nname=n\n[db]\npassword=" + secret + "\n"
+	for _, mode := range []ParserMode{ParserModeLegacy, ParserModeStandard} {
+		opts := []ConfigOption{WithInterpolation(true), WithDecryptionKey(key), WithParserMode(mode)}
+		c := NewConfigReader(opts...)
+		if _, err := c.ReadReader(strings.NewReader(text), "test.ini"); err != nil {
+			t.Fatal(err)
+		}
+		var sb strings.Builder
+		if err := c.WriteINI(&sb, false); err != nil {
+			t.Fatal(err)
+		}
+		r := NewConfigReader(opts...)
+		if _, err := r.ReadReader(strings.NewReader(sb.String()), "export.ini"); err != nil {
+			t.Fatalf("reading the export %q: %v", sb.String(), err)
+		}
+		for key, want := range map[string]string{"a": "${x}", "b": "${x}!", "db.password": "p$ss"} {
+			if got, _ := r.GetString(key); got != want {
+				t.Errorf("%s = %q, want %q", key, got, want)
+			}
+		}
+
+		// a value with a line end is quoted in the standard syntax, refused in the legacy one
+		c = NewConfigReader(WithParserMode(mode), WithDefaults(map[string]string{"note": "two\nlines"}))
+		c.ReadReader(strings.NewReader(""), "empty.ini")
+		sb.Reset()
+		err := c.WriteINI(&sb, false)
+		if mode == ParserModeLegacy {
+			if err == nil || sb.Len() > 0 {
+				t.Errorf("legacy WriteINI of a line end: %v, wrote %q", err, sb.String())
+			}
+			continue
+		}
+		r = NewConfigReader(WithParserMode(mode))
+		r.ReadReader(strings.NewReader(sb.String()), "export.ini")
+		if got, _ := r.GetString("note"); err != nil || got != "two\nlines" {
+			t.Errorf("note = %q, %v", got, err)
+		}
+	}
+}
+
+func TestWriteINIWithoutInterpolation(t *testing.T) {
+	t.Setenv(DefaultProfileEnv, "")
+	c := NewConfigReader()
+	c.ReadReader(strings.NewReader("a=$${x}\n[s]\nk=v\n"), "test.ini")
+	var sb strings.Builder
+	c.WriteINI(&sb, false)
+	if got, want := sb.String(), "a=$${x}\n\n[s]\nk=v\n"; got != want {
+		t.Errorf("WriteINI %q, want %q", got, want)
+	}
+}
+
+func TestToEnvNames(t *testing.T) {
+	t.Setenv(DefaultProfileEnv, "")
+	c := NewConfigReader(WithEnvPrefix("APP_"))
+	c.ReadReader(strings.NewReader("a/b=1\nuser@host=2\n[db.main]\nhost-name=h\n"), "test.ini")
+	if got, want := c.ToEnv(false), "APP_A_B=1\nAPP_DB_MAIN_HOST_NAME=h\nAPP_USER_HOST=2\n"; got != want {
+		t.Errorf("ToEnv %q, want %q", got, want)
+	}
+	if got := c.EnvName("a/b"); got != "APP_A_B" {
+		t.Errorf("EnvName(a/b) = %s", got)
+	}
+}
@@ -0,0 +1,263 @@
+package goutils
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/*
+
+config_ini_getters.go
+
+typed getters of ConfigReader beyond GetString, GetInt, GetInt64 and GetBool:
+
+			timeout=30s          GetDuration("timeout")
+			ratio=0.75           GetFloat64("ratio")
+			max_size=5MB         GetByteSize("max_size")     -> 5000000
+			buffer=64KiB         GetByteSize("buffer")       -> 65536
+			start=2024-01-31     GetTime("start", "2006-01-02")
+			hosts=a, b ,c        GetStringSlice("hosts", ",", true)
+			limits=cpu:2,mem:4G  GetStringMap("limits")
+
+every getter returns (value, found) like GetString, found is false when the item is
+missing or cannot be converted; the *Or variants return a default value instead
+
+*/
+
+// convert looks up an item and passes its value to conv, logging conversion failures like GetInt does
+func (c *ConfigReader) convert(getter, itemName string, conv func(s string) error) (found bool) {
+	if !c.hasItems() {
+		c.logf("goutils.ConfigReader.%s failed, there are no items read", getter)
+		return
+	}
+	s, found := c.lookup(itemName)
+	if !found {
+		return
+	}
+	if err := conv(s); err != nil {
+		c.logf("goutils.ConfigReader.%s found item but failed conversion: '%v'", getter, c.maskError(itemName, err))
+		return false
+	}
+	return true
+}
+
+// GetDuration get the duration value of a configured item, e.g. '30s' or '1h30m'
+func (c *ConfigReader) GetDuration(itemName string) (itemValue time.Duration, found bool) {
+	found = c.convert("GetDuration", itemName, func(s string) (err error) {
+		itemValue, err = time.ParseDuration(strings.TrimSpace(s))
+		return
+	})
+	return
+}
+
+// GetFloat64 get the float 64 value of a configured item
+func (c *ConfigReader) GetFloat64(itemName string) (itemValue float64, found bool) {
+	found = c.convert("GetFloat64", itemName, func(s string) (err error) {
+		itemValue, err = strconv.ParseFloat(strings.TrimSpace(s), 64)
+		return
+	})
+	return
+}
+
+// GetUint64 get the unsigned integer 64 value of a configured item
+func (c *ConfigReader) GetUint64(itemName string) (itemValue uint64, found bool) {
+	found = c.convert("GetUint64", itemName, func(s string) (err error) {
+		itemValue, err = strconv.ParseUint(strings.TrimSpace(s), 10, 64)
+		return
+	})
+	return
+}
+
+// GetByteSize get a size in bytes of a configured item, see ParseByteSize for the accepted suffixes
+func (c *ConfigReader) GetByteSize(itemName string) (itemValue uint64, found bool) {
+	found = c.convert("GetByteSize", itemName, func(s string) (err error) {
+		itemValue, err = ParseByteSize(s)
+		return
+	})
+	return
+}
+
+// GetTime get the time value of a configured item parsed with layout (see time.Parse)
+func (c *ConfigReader) GetTime(itemName, layout string) (itemValue time.Time, found bool) {
+	found = c.convert("GetTime", itemName, func(s string) (err error) {
+		itemValue, err = time.Parse(layout, strings.TrimSpace(s))
+		return
+	})
+	return
+}
+
+// GetStringSlice get the list of values of a configured item split by sep,
+// when trim is true spaces around the values are removed.
+// An empty item gives an empty list
+func (c *ConfigReader) GetStringSlice(itemName, sep string, trim bool) (itemValue []string, found bool) {
+	found = c.convert("GetStringSlice", itemName, func(s string) error {
+		itemValue = splitList(s, sep, trim)
+		return nil
+	})
+	return
+}
+
+// GetStringMap get the map value of a configured item written as 'k1:v1,k2:v2',
+// spaces around keys and values are removed
+func (c *ConfigReader) GetStringMap(itemName string) (itemValue map[string]string, found bool) {
+	found = c.convert("GetStringMap", itemName, func(s string) error {
+		m := make(map[string]string)
+		for _, pair := range splitList(s, ",", true) {
+			kv := strings.SplitN(pair, ":", 2)
+			if len(kv) != 2 {
+				return fmt.Errorf("invalid pair '%s', expected 'key:value'", pair)
+			}
+			m[strings.TrimSpace(kv[0])] = strings.TrimSpace(kv[1])
+		}
+		itemValue = m
+		return nil
+	})
+	return
+}
+
+func splitList(s, sep string, trim bool) []string {
+	if strings.TrimSpace(s) == "" {
+		return []string{}
+	}
+	list := strings.Split(s, sep)
+	if trim {
+		for i := range list {
+			list[i] = strings.TrimSpace(list[i])
+		}
+	}
+	return list
+}
+
+// byteSizeUnits maps the accepted suffixes (lower case) to their multiplier
+var byteSizeUnits = map[string]uint64{
+	"":    1,
+	"b":   1,
+	"k":   1 << 10,
+	"m":   1 << 20,
+	"g":   1 << 30,
+	"t":   1 << 40,
+	"kb":  1000,
+	"mb":  1000 * 1000,
+	"gb":  1000 * 1000 * 1000,
+	"tb":  1000 * 1000 * 1000 * 1000,
+	"kib": 1 << 10,
+	"mib": 1 << 20,
+	"gib": 1 << 30,
+	"tib": 1 << 40,
+}
+
+// ParseByteSize parses a size like '512', '10KB', '5 MB' or '1.5GiB' returning bytes.
+// KB, MB, GB and TB are powers of 1000, KiB, MiB, GiB, TiB and the single letters
+// K, M, G, T are powers of 1024; suffixes are case insensitive
+func ParseByteSize(s string) (uint64, error) {
+	t := strings.TrimSpace(s)
+	i := 0
+	for i < len(t) && (t[i] >= '0' && t[i] <= '9' || t[i] == '.') {
+		i++
+	}
+	number, unit := t[:i], strings.ToLower(strings.TrimSpace(t[i:]))
+	mult, ok := byteSizeUnits[unit]
+	if number == "" || !ok {
+		return 0, fmt.Errorf("invalid byte size '%s'", s)
+	}
+	f, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid byte size '%s': %v", s, err)
+	}
+	bytes := f * float64(mult)
+	if bytes >= math.MaxUint64 {
+		return 0, fmt.Errorf("byte size '%s' out of range", s)
+	}
+	return uint64(bytes), nil
+}
+
+// GetStringOr get the string value of a configured item or defaultValue when it is missing
+func (c *ConfigReader) GetStringOr(itemName, defaultValue string) string {
+	if v, found := c.GetString(itemName); found {
+		return v
+	}
+	return defaultValue
+}
+
+// GetIntOr get the integer value 32 bit of a configured item or defaultValue when it is missing or invalid
+func (c *ConfigReader) GetIntOr(itemName string, defaultValue int) int {
+	if v, found := c.GetInt(itemName); found {
+		return v
+	}
+	return defaultValue
+}
+
+// GetInt64Or get the integer 64 value of a configured item or defaultValue when it is missing or invalid
+func (c *ConfigReader) GetInt64Or(itemName string, defaultValue int64) int64 {
+	if v, found := c.GetInt64(itemName); found {
+		return v
+	}
+	return defaultValue
+}
+
+// GetBoolOr get the boolean value of a configured item or defaultValue when it is missing or invalid
+func (c *ConfigReader) GetBoolOr(itemName string, defaultValue bool) bool {
+	if v, found := c.GetBool(itemName); found {
+		return v
+	}
+	return defaultValue
+}
+
+// GetDurationOr get the duration value of a configured item or defaultValue when it is missing or invalid
+func (c *ConfigReader) GetDurationOr(itemName string, defaultValue time.Duration) time.Duration {
+	if v, found := c.GetDuration(itemName); found {
+		return v
+	}
+	return defaultValue
+}
+
+// GetFloat64Or get the float 64 value of a configured item or defaultValue when it is missing or invalid
+func (c *ConfigReader) GetFloat64Or(itemName string, defaultValue float64) float64 {
+	if v, found := c.GetFloat64(itemName); found {
+		return v
+	}
+	return defaultValue
+}
+
+// GetUint64Or get the unsigned integer 64 value of a configured item or defaultValue when it is missing or invalid
+func (c *ConfigReader) GetUint64Or(itemName string, defaultValue uint64) uint64 {
+	if v, found := c.GetUint64(itemName); found {
+		return v
+	}
+	return defaultValue
+}
+
+// GetByteSizeOr get the size in bytes of a configured item or defaultValue when it is missing or invalid
+func (c *ConfigReader) GetByteSizeOr(itemName string, defaultValue uint64) uint64 {
+	if v, found := c.GetByteSize(itemName); found {
+		return v
+	}
+	return defaultValue
+}
+
+// GetTimeOr get the time value of a configured item or defaultValue when it is missing or invalid
+func (c *ConfigReader) GetTimeOr(itemName, layout string, defaultValue time.Time) time.Time {
+	if v, found := c.GetTime(itemName, layout); found {
+		return v
+	}
+	return defaultValue
+}
+
+// GetStringSliceOr get the list of values of a configured item or defaultValue when it is missing
+func (c *ConfigReader) GetStringSliceOr(itemName, sep string, trim bool, defaultValue []string) []string {
+	if v, found := c.GetStringSlice(itemName, sep, trim); found {
+		return v
+	}
+	return defaultValue
+}
+
+// GetStringMapOr get the map value of a configured item or defaultValue when it is missing or invalid
+func (c *ConfigReader) GetStringMapOr(itemName string, defaultValue map[string]string) map[string]string {
+	if v, found := c.GetStringMap(itemName); found {
+		return v
+	}
+	return defaultValue
+}
@@ -0,0 +1,166 @@
+package goutils
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+/*
+
+config_ini_interpolate.go
+
+with WithInterpolation(true) values read by ConfigReader can reference other values:
+
+			base_dir=/srv/app
+			log_dir=${base_dir}/log            -> /srv/app/log
+			home=${env:HOME}                   -> value of $HOME
+			data_dir=${data_root:-/tmp}/data   -> /tmp/data when data_root is not configured
+			price=10$$                         -> 10$
+			url=${api_url:-${base_url}/api}    -> defaults can hold references
+
+inside a section an unqualified reference is looked up first in the same section,
+then as a qualified name ('${database.host}' or a key outside any section).
+Interpolation is off by default, so files written before it keep '$' as written
+
+*/
+
+// WithInterpolation enables or disables (the default) the expansion of ${...} references
+func WithInterpolation(enabled bool) ConfigOption {
+	return func(c *ConfigReader) {
+		c.interpolation = enabled
+	}
+}
+
+// interpolator expands the references of a set of raw items
+type interpolator struct {
+	c        *ConfigReader
+	raw      map[string]string
+	resolved map[string]string
+	visiting []string
+}
+
+// interpolateItems returns a copy of items with every reference expanded
+func (c *ConfigReader) interpolateItems(items map[string]string) (map[string]string, error) {
+	in := &interpolator{c: c, raw: items, resolved: make(map[string]string, len(items))}
+	for k := range items {
+		if _, err := in.resolve(k); err != nil {
+			return nil, err
+		}
+	}
+	return in.resolved, nil
+}
+
+func (in *interpolator) resolve(key string) (string, error) {
+	if v, ok := in.resolved[key]; ok {
+		return v, nil
+	}
+	for i, k := range in.visiting {
+		if k == key {
+			chain := append(append([]string(nil), in.visiting[i:]...), key)
+			return "", fmt.Errorf("reference cycle: %s", strings.Join(chain, " -> "))
+		}
+	}
+	in.visiting = append(in.visiting, key)
+	v, err := in.expand(in.raw[key], sectionOfKey(key))
+	in.visiting = in.visiting[:len(in.visiting)-1]
+	if err != nil {
+		if len(in.visiting) == 0 {
+			err = fmt.Errorf("key '%s': %v", key, err)
+		}
+		return "", err
+	}
+	in.resolved[key] = v
+	return v, nil
+}
+
+// expand replaces '$$' with '$' and every '${ref}' with its value
+func (in *interpolator) expand(s, section string) (string, error) {
+	if !strings.Contains(s, "$") {
+		return s, nil
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '$' || i+1 >= len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+		if s[i+1] == '$' {
+			b.WriteByte('$')
+			i++
+			continue
+		}
+		end := closingBrace(s[i:])
+		if s[i+1] != '{' || end < 0 {
+			b.WriteByte(s[i])
+			continue
+		}
+		v, err := in.reference(s[i+2:i+end], section)
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(v)
+		i += end
+	}
+	return b.String(), nil
+}
+
+// closingBrace returns the position in s, starting with '${', of the '}' closing it,
+// skipping the references nested in a default; -1 when it is not closed
+func closingBrace(s string) int {
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '$' && i+1 < len(s) && s[i+1] == '$':
+			i++
+		case s[i] == '$' && i+1 < len(s) && s[i+1] == '{':
+			depth++
+			i++
+		case s[i] == '}':
+			if depth--; depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// reference returns the value of the content of a '${...}'
+func (in *interpolator) reference(ref, section string) (string, error) {
+	name, def, hasDefault := ref, "", false
+	if i := strings.Index(ref, ":-"); i >= 0 {
+		name, def, hasDefault = ref[:i], ref[i+2:], true
+	}
+	name = strings.TrimSpace(name)
+
+	if strings.HasPrefix(name, "env:") {
+		if v, ok := os.LookupEnv(strings.TrimPrefix(name, "env:")); ok {
+			return v, nil
+		}
+	} else {
+		candidates := []string{name}
+		if section != "" {
+			candidates = []string{sectionKey(section, name), name}
+		}
+		for _, k := range candidates {
+			if v, ok := in.c.lookupEnv(k); ok {
+				return v, nil
+			}
+			if _, ok := in.raw[k]; ok {
+				return in.resolve(k)
+			}
+		}
+	}
+	if hasDefault {
+		return in.expand(def, section)
+	}
+	return "", fmt.Errorf("undefined reference '${%s}'", ref)
+}
+
+// sectionOfKey returns the section of a qualified item name
+func sectionOfKey(key string) string {
+	if i := strings.LastIndexByte(key, '.'); i >= 0 {
+		return key[:i]
+	}
+	return ""
+}
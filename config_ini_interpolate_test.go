@@ -0,0 +1,74 @@
+package goutils
+
+import (
+	"strings"
+	"testing"
+)
+
+func readInterpolated(t *testing.T, text string, opts ...ConfigOption) (*ConfigReader, error) {
+	t.Helper()
+	c := NewConfigReader(append([]ConfigOption{WithInterpolation(true)}, opts...)...)
+	_, err := c.ReadReader(strings.NewReader(text), "test.ini")
+	return c, err
+}
+
+func TestInterpolationOffByDefault(t *testing.T) {
+	t.Setenv(DefaultProfileEnv, "")
+	c := NewConfigReader()
+	if _, err := c.ReadReader(strings.NewReader("pass=ab$$cd\ntmpl=hello ${name}\nport=80\n"), "test.ini"); err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	for key, want := range map[string]string{"pass": "ab$$cd", "tmpl": "hello ${name}", "port": "80"} {
+		if got, _ := c.GetString(key); got != want {
+			t.Errorf("%s = %q, want %q", key, got, want)
+		}
+	}
+}
+
+func TestInterpolationEscape(t *testing.T) {
+	t.Setenv(DefaultProfileEnv, "")
+	c, err := readInterpolated(t, "price=10$$\npass=ab$$cd\nliteral=$${name}\nname=x\n")
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	for key, want := range map[string]string{"price": "10$", "pass": "ab$cd", "literal": "${name}"} {
+		if got, _ := c.GetString(key); got != want {
+			t.Errorf("%s = %q, want %q", key, got, want)
+		}
+	}
+}
+
+func TestInterpolationReferences(t *testing.T) {
+	t.Setenv(DefaultProfileEnv, "")
+	c, err := readInterpolated(t, "base=/srv\nlog=${base}/log\nurl=${api:-${base}/api}\nnested=${a:-${b:-${base}}}\n[db]\nhost=h\nurl=${host}:${port:-5432}\n")
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	for key, want := range map[string]string{"log": "/srv/log", "url": "/srv/api", "nested": "/srv", "db.url": "h:5432"} {
+		if got, _ := c.GetString(key); got != want {
+			t.Errorf("%s = %q, want %q", key, got, want)
+		}
+	}
+}
+
+func TestInterpolationErrors(t *testing.T) {
+	t.Setenv(DefaultProfileEnv, "")
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"a=${b}\nb=${a}\n", "reference cycle"},
+		{"a=${a}\n", "reference cycle: a -> a"},
+		{"a=${b}\nb=${c}\nc=${a}\n", "reference cycle"},
+		{"a=${missing}\n", "undefined reference '${missing}'"},
+	}
+	for _, tt := range tests {
+		c, err := readInterpolated(t, tt.text)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Read(%q) error = %v, want %q", tt.text, err, tt.want)
+		}
+		if n := len(c.Keys()); n != 0 {
+			t.Errorf("Read(%q) kept %d items after a failure", tt.text, n)
+		}
+	}
+}
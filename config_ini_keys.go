@@ -0,0 +1,199 @@
+package goutils
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+/*
+
+config_ini_keys.go
+
+enumeration of the configured keys and views over a part of them:
+
+			conf.Keys()                     // [db.primary.host db.primary.port db.replica.host name]
+			conf.HasKey("db.primary.host")  // true
+			conf.KeysWithPrefix("db.")      // [db.primary.host db.primary.port db.replica.host]
+
+			primary := conf.Sub("db.primary")  // same as Sub("db.primary.")
+			host, _ := primary.GetString("host")
+			primary.Keys()                  // [host port]
+			primary.Unmarshal(&dbConfig)
+
+a view reads the ConfigReader it comes from, so it sees the values of every reload;
+Sub is the same as Section, with the name of a nested section or of a key prefix
+
+*/
+
+// Keys returns the names of the configured items, sorted
+func (c *ConfigReader) Keys() []string {
+	return c.KeysWithPrefix("")
+}
+
+// HasKey tells whether an item is configured, in a file or by an environment override
+func (c *ConfigReader) HasKey(itemName string) bool {
+	_, found := c.lookup(itemName)
+	return found
+}
+
+// KeysWithPrefix returns the names of the configured items starting with prefix, sorted
+func (c *ConfigReader) KeysWithPrefix(prefix string) []string {
+	keys := []string{}
+	for k := range c.currentItems() {
+		if strings.HasPrefix(k, prefix) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Sub returns a view over the items whose name starts with prefix followed by '.',
+// its getters take names relative to the prefix
+func (c *ConfigReader) Sub(prefix string) *ConfigSection {
+	return c.Section(strings.TrimSuffix(prefix, "."))
+}
+
+// Sub returns a view over the items of the section whose name starts with prefix followed by '.'
+func (s *ConfigSection) Sub(prefix string) *ConfigSection {
+	return s.reader.Sub(s.itemName(strings.TrimSuffix(prefix, ".")))
+}
+
+// Reader returns the ConfigReader the view comes from
+func (s *ConfigSection) Reader() *ConfigReader {
+	return s.reader
+}
+
+// itemName returns the qualified name of a key of the section
+func (s *ConfigSection) itemName(key string) string {
+	return sectionKey(s.name, key)
+}
+
+// Keys returns the keys of the section, relative to its name and sorted
+func (s *ConfigSection) Keys() []string {
+	return s.KeysWithPrefix("")
+}
+
+// HasKey tells whether a key of the section is configured
+func (s *ConfigSection) HasKey(key string) bool {
+	return s.reader.HasKey(s.itemName(key))
+}
+
+// KeysWithPrefix returns the keys of the section starting with prefix, relative to its name and sorted.
+// For the section with an empty name they are the keys found before any section header
+func (s *ConfigSection) KeysWithPrefix(prefix string) []string {
+	if s.name == "" {
+		snap := s.reader.Snapshot()
+		keys := []string{}
+		for _, k := range snap.KeysWithPrefix(prefix) {
+			if section, _ := snap.splitItemName(k); section == "" {
+				keys = append(keys, k)
+			}
+		}
+		return keys
+	}
+	keys := s.reader.KeysWithPrefix(s.name + "." + prefix)
+	for i, k := range keys {
+		keys[i] = k[len(s.name)+1:]
+	}
+	return keys
+}
+
+// Unmarshal fills the struct pointed by v with the keys of the section, see ConfigReader.Unmarshal
+func (s *ConfigSection) Unmarshal(v interface{}) error {
+	if s.name == "" {
+		return s.reader.unmarshal(v, "")
+	}
+	return s.reader.unmarshal(v, s.name+".")
+}
+
+// GetDuration get the duration value of a key in the section
+func (s *ConfigSection) GetDuration(key string) (itemValue time.Duration, found bool) {
+	return s.reader.GetDuration(s.itemName(key))
+}
+
+// GetFloat64 get the float 64 value of a key in the section
+func (s *ConfigSection) GetFloat64(key string) (itemValue float64, found bool) {
+	return s.reader.GetFloat64(s.itemName(key))
+}
+
+// GetUint64 get the unsigned integer 64 value of a key in the section
+func (s *ConfigSection) GetUint64(key string) (itemValue uint64, found bool) {
+	return s.reader.GetUint64(s.itemName(key))
+}
+
+// GetByteSize get the size in bytes of a key in the section
+func (s *ConfigSection) GetByteSize(key string) (itemValue uint64, found bool) {
+	return s.reader.GetByteSize(s.itemName(key))
+}
+
+// GetTime get the time value of a key in the section
+func (s *ConfigSection) GetTime(key, layout string) (itemValue time.Time, found bool) {
+	return s.reader.GetTime(s.itemName(key), layout)
+}
+
+// GetStringSlice get the list of values of a key in the section
+func (s *ConfigSection) GetStringSlice(key, sep string, trim bool) (itemValue []string, found bool) {
+	return s.reader.GetStringSlice(s.itemName(key), sep, trim)
+}
+
+// GetStringMap get the map value of a key in the section
+func (s *ConfigSection) GetStringMap(key string) (itemValue map[string]string, found bool) {
+	return s.reader.GetStringMap(s.itemName(key))
+}
+
+// GetStringOr get the string value of a key in the section or defaultValue when it is missing
+func (s *ConfigSection) GetStringOr(key, defaultValue string) string {
+	return s.reader.GetStringOr(s.itemName(key), defaultValue)
+}
+
+// GetIntOr get the integer value 32 bit of a key in the section or defaultValue when it is missing or invalid
+func (s *ConfigSection) GetIntOr(key string, defaultValue int) int {
+	return s.reader.GetIntOr(s.itemName(key), defaultValue)
+}
+
+// GetInt64Or get the integer 64 value of a key in the section or defaultValue when it is missing or invalid
+func (s *ConfigSection) GetInt64Or(key string, defaultValue int64) int64 {
+	return s.reader.GetInt64Or(s.itemName(key), defaultValue)
+}
+
+// GetBoolOr get the boolean value of a key in the section or defaultValue when it is missing or invalid
+func (s *ConfigSection) GetBoolOr(key string, defaultValue bool) bool {
+	return s.reader.GetBoolOr(s.itemName(key), defaultValue)
+}
+
+// GetDurationOr get the duration value of a key in the section or defaultValue when it is missing or invalid
+func (s *ConfigSection) GetDurationOr(key string, defaultValue time.Duration) time.Duration {
+	return s.reader.GetDurationOr(s.itemName(key), defaultValue)
+}
+
+// GetFloat64Or get the float 64 value of a key in the section or defaultValue when it is missing or invalid
+func (s *ConfigSection) GetFloat64Or(key string, defaultValue float64) float64 {
+	return s.reader.GetFloat64Or(s.itemName(key), defaultValue)
+}
+
+// GetUint64Or get the unsigned integer 64 value of a key in the section or defaultValue when it is missing or invalid
+func (s *ConfigSection) GetUint64Or(key string, defaultValue uint64) uint64 {
+	return s.reader.GetUint64Or(s.itemName(key), defaultValue)
+}
+
+// GetByteSizeOr get the size in bytes of a key in the section or defaultValue when it is missing or invalid
+func (s *ConfigSection) GetByteSizeOr(key string, defaultValue uint64) uint64 {
+	return s.reader.GetByteSizeOr(s.itemName(key), defaultValue)
+}
+
+// GetTimeOr get the time value of a key in the section or defaultValue when it is missing or invalid
+func (s *ConfigSection) GetTimeOr(key, layout string, defaultValue time.Time) time.Time {
+	return s.reader.GetTimeOr(s.itemName(key), layout, defaultValue)
+}
+
+// GetStringSliceOr get the list of values of a key in the section or defaultValue when it is missing
+func (s *ConfigSection) GetStringSliceOr(key, sep string, trim bool, defaultValue []string) []string {
+	return s.reader.GetStringSliceOr(s.itemName(key), sep, trim, defaultValue)
+}
+
+// GetStringMapOr get the map value of a key in the section or defaultValue when it is missing or invalid
+func (s *ConfigSection) GetStringMapOr(key string, defaultValue map[string]string) map[string]string {
+	return s.reader.GetStringMapOr(s.itemName(key), defaultValue)
+}
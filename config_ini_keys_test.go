@@ -0,0 +1,36 @@
+package goutils
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestKeysAndViews(t *testing.T) {
+	t.Setenv(DefaultProfileEnv, "")
+	c := NewConfigReader()
+	c.ReadReader(strings.NewReader("name=n\nlog.level=debug\n[db.primary]\nhost=h1\nport=1\n[db.replica]\nhost=h2\n"), "test.ini")
+
+	tests := []struct {
+		got  []string
+		want string
+	}{
+		{c.Keys(), "db.primary.host,db.primary.port,db.replica.host,log.level,name"},
+		{c.KeysWithPrefix("db."), "db.primary.host,db.primary.port,db.replica.host"},
+		{c.Sub("db.primary").Keys(), "host,port"},
+		{c.Sub("db.").Keys(), "primary.host,primary.port,replica.host"},
+		{c.Sub("db").Sub("replica").Keys(), "host"},
+		{c.Section("").Keys(), "log.level,name"},
+		{c.Section("").KeysWithPrefix("n"), "name"},
+	}
+	for i, tt := range tests {
+		if got := strings.Join(tt.got, ","); got != tt.want {
+			t.Errorf("%d: keys %s, want %s", i, got, tt.want)
+		}
+	}
+	if !c.HasKey("db.primary.host") || c.HasKey("db.primary") || !c.Sub("db.primary").HasKey("port") {
+		t.Error("HasKey")
+	}
+	if got := c.Sub("db.primary").GetIntOr("port", 0); got != 1 {
+		t.Errorf("port = %d", got)
+	}
+}
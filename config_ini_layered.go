@@ -0,0 +1,89 @@
+package goutils
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+/*
+
+config_ini_layered.go
+
+configuration merged from several files, later files overriding earlier ones:
+
+			conf.ReadLayered("/usr/share/myapp/default.ini", "/etc/myapp/site.ini")
+			conf.ReadDir("/etc/myapp/conf.d", "*.ini")   // files in name order
+
+a file can include another one, relative paths are resolved from the folder of the
+including file and the included keys start in the section of the directive:
+
+			!include common.ini
+			include=/etc/myapp/secrets.ini
+
+Set and Save change the last file read; Watch polls every file read and, for ReadDir,
+the folder itself so that added or removed files are seen
+
+*/
+
+// ReadLayered reads configuration files in order, the values of later files override earlier ones
+func (c *ConfigReader) ReadLayered(configPaths ...string) (numItemsFound int, err error) {
+	if len(configPaths) == 0 {
+		return 0, errors.New("goutils.ConfigReader.ReadLayered: no config file")
+	}
+	paths := append([]string(nil), configPaths...)
+	failed := &parsedConfig{path: paths[len(paths)-1], savePath: paths[len(paths)-1]}
+	for _, p := range paths {
+		failed.files = append(failed.files, watchedFile{path: p})
+	}
+	return c.load(failed, func() (*parsedConfig, error) {
+		return c.parseFiles(paths, nil)
+	})
+}
+
+// ReadDir reads the files of a folder matching pattern (see filepath.Match) in name order,
+// the values of later files override earlier ones. It fails when dir is missing or is not
+// a folder; the folders matching pattern are skipped
+func (c *ConfigReader) ReadDir(dir, pattern string) (numItemsFound int, err error) {
+	failed := &parsedConfig{path: dir, files: []watchedFile{{path: dir}}}
+	return c.load(failed, func() (*parsedConfig, error) {
+		info, err := os.Stat(dir)
+		if err == nil && !info.IsDir() {
+			err = errors.New("not a directory")
+		}
+		if err != nil {
+			c.logf("goutils.ConfigReader.ReadDir(%s, %s) error: %s", dir, pattern, err)
+			return nil, fmt.Errorf("goutils.ConfigReader.ReadDir(%s, %s): %v", dir, pattern, err)
+		}
+		matches, err := filepath.Glob(filepath.Join(dir, pattern))
+		if err != nil {
+			c.logf("goutils.ConfigReader.ReadDir(%s, %s) error: %s", dir, pattern, err)
+			return nil, fmt.Errorf("goutils.ConfigReader.ReadDir(%s, %s): %v", dir, pattern, err)
+		}
+		var paths []string
+		for _, p := range matches {
+			if info, err := os.Stat(p); err == nil && info.IsDir() {
+				continue
+			}
+			paths = append(paths, p)
+		}
+		return c.parseFiles(paths, []string{dir})
+	})
+}
+
+// parseIncludeDirective recognizes '!include path' and 'include=path' lines
+func parseIncludeDirective(line string) (path string, ok bool) {
+	t := strings.TrimSpace(line)
+	switch {
+	case strings.HasPrefix(t, "!include "):
+		path = strings.TrimSpace(strings.TrimPrefix(t, "!include "))
+	case strings.HasPrefix(t, "include") && strings.HasPrefix(strings.TrimSpace(t[len("include"):]), "="):
+		path = strings.TrimSpace(t[strings.IndexByte(t, '=')+1:])
+	default:
+		return
+	}
+	ok = path != ""
+	return
+}
@@ -0,0 +1,50 @@
+package goutils
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReadLayered(t *testing.T) {
+	t.Setenv(DefaultProfileEnv, "")
+	paths := writeConfigFiles(t,
+		"default.ini", "name=a\nport=80\n[db]\nhost=h\n",
+		"site.ini", "port=8080\n!include extra.ini\n",
+		"extra.ini", "user=u\n")
+	c := NewConfigReader()
+	if _, err := c.ReadLayered(paths[0], paths[1]); err != nil {
+		t.Fatal(err)
+	}
+	for key, want := range map[string]string{"name": "a", "port": "8080", "db.host": "h", "user": "u"} {
+		if got, _ := c.GetString(key); got != want {
+			t.Errorf("%s = %q, want %q", key, got, want)
+		}
+	}
+}
+
+func TestReadDir(t *testing.T) {
+	t.Setenv(DefaultProfileEnv, "")
+	paths := writeConfigFiles(t, "10-base.ini", "a=1\nb=1\n", "20-site.ini", "b=2\n", "notes.txt", "a=3\n")
+	dir := filepath.Dir(paths[0])
+	if err := os.Mkdir(filepath.Join(dir, "30-old.ini"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	c := NewConfigReader()
+	if _, err := c.ReadDir(dir, "*.ini"); err != nil {
+		t.Fatal(err)
+	}
+	if got := c.ToEnv(false); got != "A=1\nB=2\n" {
+		t.Errorf("items %q", got)
+	}
+
+	for _, bad := range []string{filepath.Join(dir, "missing"), paths[0]} {
+		c := NewConfigReader()
+		if n, err := c.ReadDir(bad, "*.ini"); err == nil || n != 0 {
+			t.Errorf("ReadDir(%s) = %d, %v", bad, n, err)
+		} else if !strings.Contains(err.Error(), "ReadDir") {
+			t.Errorf("ReadDir(%s) error %v", bad, err)
+		}
+	}
+}
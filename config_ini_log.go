@@ -0,0 +1,131 @@
+package goutils
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+/*
+
+config_ini_log.go
+
+ConfigReader writes nothing to the log unless a logger is set:
+
+			conf := goutils.NewConfigReader(goutils.WithLogger(log.Default()))
+			// with log/slog: goutils.WithLogger(slog.NewLogLogger(handler, slog.LevelDebug))
+
+the values of secret keys are never written in diagnostics, PrintItems and errors,
+a key is secret when it matches one of the patterns, case insensitive, where '*' matches
+any text and '?' any character:
+
+			conf := goutils.NewConfigReader(goutils.WithSecretPatterns(append(goutils.DefaultSecretPatterns, "*pin*")...))
+
+*/
+
+// ConfigLogger receives the diagnostics of ConfigReader, *log.Logger satisfies it
+type ConfigLogger interface {
+	Printf(format string, v ...interface{})
+}
+
+// DefaultSecretPatterns are the patterns of the keys whose values are masked
+var DefaultSecretPatterns = []string{"*password*", "*passwd*", "*secret*", "*token*", "*api_key*", "*apikey*", "*private_key*"}
+
+// maskedValue replaces the value of secret keys
+const maskedValue = "******"
+
+// WithLogger sets where ConfigReader writes its diagnostics, nothing is written by default
+func WithLogger(logger ConfigLogger) ConfigOption {
+	return func(c *ConfigReader) {
+		c.logger = logger
+	}
+}
+
+// WithSecretPatterns replaces DefaultSecretPatterns as the patterns of the keys whose values are masked
+func WithSecretPatterns(patterns ...string) ConfigOption {
+	return func(c *ConfigReader) {
+		c.secretPatterns = append([]string{}, patterns...)
+	}
+}
+
+// logf writes a diagnostic to the configured logger
+func (c *ConfigReader) logf(format string, v ...interface{}) {
+	if c.logger != nil {
+		c.logger.Printf(format, v...)
+	}
+}
+
+// IsSecret tells whether the value of an item must not be shown,
+// values read encrypted are always secret
+func (c *ConfigReader) IsSecret(itemName string) bool {
+	if c.origin(itemName).encrypted {
+		return true
+	}
+	if v, ok := c.lookupRawEnv(itemName); ok && IsEncryptedValue(v) {
+		return true
+	}
+	patterns := c.secretPatterns
+	if patterns == nil {
+		patterns = DefaultSecretPatterns
+	}
+	name := strings.ToLower(itemName)
+	for _, p := range patterns {
+		if wildcardMatch(strings.ToLower(p), name) {
+			return true
+		}
+	}
+	return false
+}
+
+// wildcardMatch tells whether s matches pattern, '*' matches any text and '?' any byte
+func wildcardMatch(pattern, s string) bool {
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case '*':
+			for i := len(s); i >= 0; i-- {
+				if wildcardMatch(pattern[1:], s[i:]) {
+					return true
+				}
+			}
+			return false
+		case '?':
+			if len(s) == 0 {
+				return false
+			}
+		default:
+			if len(s) == 0 || s[0] != pattern[0] {
+				return false
+			}
+		}
+		pattern, s = pattern[1:], s[1:]
+	}
+	return len(s) == 0
+}
+
+// maskValue returns value, or a mask when the item is secret
+func (c *ConfigReader) maskValue(itemName, value string) string {
+	if c.IsSecret(itemName) {
+		return maskedValue
+	}
+	return value
+}
+
+// maskLine returns a line of a file, or a mask when it looks like it holds a secret
+func (c *ConfigReader) maskLine(line string) string {
+	if c.IsSecret(line) {
+		return maskedValue
+	}
+	return line
+}
+
+// maskError returns the conversion error of an item without the value when the item is secret
+func (c *ConfigReader) maskError(itemName string, err error) error {
+	if err == nil || !c.IsSecret(itemName) {
+		return err
+	}
+	var numErr *strconv.NumError
+	if errors.As(err, &numErr) {
+		return numErr.Err
+	}
+	return errors.New("invalid value")
+}
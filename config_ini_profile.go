@@ -0,0 +1,286 @@
+package goutils
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+/*
+
+config_ini_profile.go
+
+a single file holds the shared values and the overrides of each environment profile:
+
+			port=8080
+			port@production=443          # key of a profile
+
+			[database]
+			host=localhost
+
+			[database@production]        # keys of a section for a profile
+			host=db.prod.internal
+
+			[@staging]                   # keys outside sections for a profile
+			port=8443
+
+			[production]                 # same as [@production], production being a profile
+			log_level=warn
+
+the profile is the one given to WithProfile or SelectProfile, else the value of the
+environment variable APP_PROFILE (see WithProfileEnv); with no profile only the shared
+values are used. The items of the other profiles are dropped, ProfileOf tells which
+profile supplied a value:
+
+			conf := goutils.NewConfigReader(goutils.WithProfile("production"))
+			conf.Read("app.ini")
+			conf.GetString("database.host")  // db.prod.internal
+			conf.ProfileOf("database.host")  // production
+			conf.SelectProfile("staging")    // reads the files again
+
+'@name' introduces a profile only when name is the selected profile, is declared with
+WithProfiles or was used in a section header before ('[section@name]' or '[@name]'):
+other keys keep their '@', as in 'alice@example.com=admin'. A plain section is a profile
+section when its name is the selected profile or a profile used with '@'. Declaring the
+profiles also drops the keys of the ones not selected wherever they are written:
+
+			conf := goutils.NewConfigReader(goutils.WithProfiles("production", "staging"))
+
+*/
+
+// DefaultProfileEnv is the environment variable selecting the profile when none is given
+const DefaultProfileEnv = "APP_PROFILE"
+
+// WithProfile selects the profile whose values override the shared ones
+func WithProfile(name string) ConfigOption {
+	return func(c *ConfigReader) {
+		c.profile = name
+	}
+}
+
+// WithProfileEnv sets the environment variable selecting the profile, instead of APP_PROFILE
+func WithProfileEnv(name string) ConfigOption {
+	return func(c *ConfigReader) {
+		c.profileEnv = name
+	}
+}
+
+// WithProfiles declares the names of the profiles used in the files, so that 'key@name'
+// is a key of a profile even when no section header of that profile comes before it
+func WithProfiles(names ...string) ConfigOption {
+	return func(c *ConfigReader) {
+		c.declaredProfiles = append(c.declaredProfiles, names...)
+	}
+}
+
+// SelectProfile changes the profile and reads the files again, an empty name goes back to
+// the profile of the environment variable. When reading fails the previous items are kept
+func (c *ConfigReader) SelectProfile(name string) error {
+	if c.readOnly {
+		return fmt.Errorf("goutils.ConfigReader.SelectProfile(%s): %s", name, errReadOnly)
+	}
+	c.lock.Lock()
+	previousProfile := c.profile
+	c.profile = name
+	reload := c.reload
+	c.lock.Unlock()
+	if reload == nil {
+		// nothing read yet, the profile is used by the next Read
+		return nil
+	}
+
+	pc, err := reload()
+	if err != nil {
+		c.lock.Lock()
+		c.profile = previousProfile
+		c.lock.Unlock()
+		c.logf("goutils.ConfigReader.SelectProfile(%s) failed, keeping previous items: %s", name, err)
+		return fmt.Errorf("goutils.ConfigReader.SelectProfile(%s): %v", name, err)
+	}
+	before := c.Snapshot()
+	previous := c.install(pc, reload)
+	c.logf("goutils.ConfigReader.SelectProfile(%s) reloaded %d items", name, len(pc.items))
+	c.notifyChange(before, previous, pc.items)
+	return nil
+}
+
+// Profile returns the profile used for the items read, empty when none
+func (c *ConfigReader) Profile() string {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.activeProfile
+}
+
+// Profiles returns the names of the profiles found in the files, sorted
+func (c *ConfigReader) Profiles() []string {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return append([]string(nil), c.profiles...)
+}
+
+// ProfileOf returns the profile that supplied the value of an item, empty for a shared value
+func (c *ConfigReader) ProfileOf(itemName string) string {
+	return c.origin(itemName).profile
+}
+
+// selectedProfile returns the profile to use for the next read
+func (c *ConfigReader) selectedProfile() string {
+	c.lock.RLock()
+	profile := c.profile
+	c.lock.RUnlock()
+	if profile != "" {
+		return profile
+	}
+	name := c.profileEnv
+	if name == "" {
+		name = DefaultProfileEnv
+	}
+	return strings.TrimSpace(os.Getenv(name))
+}
+
+// profileValue is a value of the selected profile, set at the end of the layer it was read in
+type profileValue struct {
+	name   string // shared item name
+	value  string
+	origin itemOrigin
+}
+
+// deferProfile holds back the item of a profile: the values of the selected profile are set
+// by applyLayerProfile, those of the other profiles are dropped. It returns false for a shared item
+func (l *configLoader) deferProfile(itemName, value string, o itemOrigin) bool {
+	base, profile, ok := splitProfile(itemName, l.pc.profile, l.known)
+	if !ok {
+		return false
+	}
+	if l.found == nil {
+		l.found = make(map[string]bool)
+	}
+	l.found[profile] = true
+	if profile == l.pc.profile {
+		o.profile = profile
+		l.layer = append(l.layer, profileValue{name: base, value: value, origin: o})
+	}
+	return true
+}
+
+// knowProfiles makes known the selected profile and the declared ones
+func (l *configLoader) knowProfiles() {
+	l.known = make(map[string]bool)
+	for _, p := range append([]string{l.pc.profile}, l.c.declaredProfiles...) {
+		if isProfileName(p) {
+			l.known[p] = true
+		}
+	}
+}
+
+// knowSectionProfile makes known the profile of a '[section@name]' or '[@name]' header
+func (l *configLoader) knowSectionProfile(section string) {
+	if i := strings.LastIndexByte(section, '@'); i >= 0 && isProfileName(section[i+1:]) {
+		l.known[section[i+1:]] = true
+	}
+}
+
+// applyLayerProfile sets the values of the selected profile read in the layer just read
+// (a file with its includes, or the defaults): they override the shared values of the same
+// layer and of the layers before, while the later layers still override them
+func (l *configLoader) applyLayerProfile() {
+	layer := l.layer
+	l.layer = nil
+	for _, v := range layer {
+		l.setItem(v.name, v.value, v.origin)
+		if s := sectionOfKey(v.name); s != "" && !containsString(l.pc.sections, s) {
+			l.pc.sections = append(l.pc.sections, s)
+		}
+		l.c.logf("goutils.ConfigReader.Read(%s) profile %s sets '%s'", v.origin.path, v.origin.profile, v.name)
+	}
+}
+
+// applyProfile drops the plain sections named like a profile not selected
+// and the sections of the profiles, once every layer has been read
+func (l *configLoader) applyProfile() {
+	l.applyLayerProfile()
+	pc := l.pc
+	if len(l.found) == 0 {
+		return
+	}
+	items := make(map[string]string, len(pc.items))
+	origins := make(map[string]itemOrigin, len(pc.origins))
+	history := make(map[string][]itemOrigin, len(pc.items))
+	for k, v := range pc.items {
+		// a plain section named like a known profile belongs to that profile
+		if p := profileSection(k, l.found); p != "" && p != pc.profile && pc.origins[k].profile == "" {
+			continue
+		}
+		items[k] = v
+		origins[k] = pc.origins[k]
+		history[k] = pc.history[k]
+	}
+
+	var sections []string
+	for _, s := range pc.sections {
+		if profileOfItem(s+".", pc.profile, l.known) == "" && profileSection(s+".", l.found) == "" && !containsString(sections, s) {
+			sections = append(sections, s)
+		}
+	}
+
+	pc.profiles = pc.profiles[:0]
+	for p := range l.found {
+		pc.profiles = append(pc.profiles, p)
+	}
+	sort.Strings(pc.profiles)
+	pc.items, pc.origins, pc.history, pc.sections = items, origins, history, sections
+}
+
+// profileSection returns the profile of found whose name is the first part of itemName
+func profileSection(itemName string, found map[string]bool) string {
+	if i := strings.IndexByte(itemName, '.'); i > 0 && found[itemName[:i]] {
+		return itemName[:i]
+	}
+	return ""
+}
+
+// profileOfItem returns the profile of a qualified item name, empty for a shared item
+func profileOfItem(itemName, selected string, known map[string]bool) string {
+	_, profile, _ := splitProfile(itemName, selected, known)
+	return profile
+}
+
+// splitProfile recognizes the item names of a profile: 'key@p', 'section.key@p',
+// 'section@p.key', '@p.key' when p is selected or known and, for the selected profile, 'p.key'
+func splitProfile(itemName, selected string, known map[string]bool) (base, profile string, ok bool) {
+	if i := strings.IndexByte(itemName, '@'); i >= 0 {
+		rest, tail := itemName[i+1:], ""
+		if j := strings.IndexByte(rest, '.'); j >= 0 {
+			rest, tail = rest[:j], rest[j:]
+		}
+		if isProfileName(rest) && (rest == selected || known[rest]) {
+			switch {
+			case tail == "":
+				return itemName[:i], rest, true
+			case i == 0:
+				return tail[1:], rest, true
+			default:
+				return itemName[:i] + tail, rest, true
+			}
+		}
+	}
+	if selected != "" && strings.HasPrefix(itemName, selected+".") {
+		return itemName[len(selected)+1:], selected, true
+	}
+	return "", "", false
+}
+
+// isProfileName tells whether name is made of letters, digits, '_' and '-'
+func isProfileName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i := 0; i < len(name); i++ {
+		b := name[i]
+		if !(b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z' || b >= '0' && b <= '9' || b == '_' || b == '-') {
+			return false
+		}
+	}
+	return true
+}
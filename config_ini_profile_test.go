@@ -0,0 +1,171 @@
+package goutils
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeConfigFiles writes the files named by files in a temporary folder, returning their paths
+func writeConfigFiles(t *testing.T, files ...string) []string {
+	t.Helper()
+	dir := t.TempDir()
+	var paths []string
+	for i := 0; i+1 < len(files); i += 2 {
+		p := filepath.Join(dir, files[i])
+		if err := os.WriteFile(p, []byte(files[i+1]), 0644); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, p)
+	}
+	return paths
+}
+
+func TestProfileSections(t *testing.T) {
+	paths := writeConfigFiles(t, "app.ini", "port=8080\nport@production=443\n[database]\nhost=localhost\n[database@production]\nhost=db.prod\n[@staging]\nport=8443\n[production]\nlog_level=warn\n")
+	c := NewConfigReader(WithProfile("production"))
+	if _, err := c.Read(paths[0]); err != nil {
+		t.Fatal(err)
+	}
+	for key, want := range map[string]string{"port": "443", "database.host": "db.prod", "log_level": "warn"} {
+		if got, _ := c.GetString(key); got != want {
+			t.Errorf("%s = %q, want %q", key, got, want)
+		}
+	}
+	if got := c.ProfileOf("database.host"); got != "production" {
+		t.Errorf("ProfileOf(database.host) = %q", got)
+	}
+	if got := strings.Join(c.Profiles(), ","); got != "production,staging" {
+		t.Errorf("Profiles() = %s", got)
+	}
+	if got := strings.Join(c.Keys(), ","); got != "database.host,log_level,port" {
+		t.Errorf("Keys() = %s", got)
+	}
+}
+
+func TestProfileSameFileOrder(t *testing.T) {
+	// in the same file the profile wins wherever it is written
+	paths := writeConfigFiles(t, "app.ini", "port@production=443\nport=8080\n")
+	c := NewConfigReader(WithProfile("production"))
+	c.Read(paths[0])
+	if got, _ := c.GetString("port"); got != "443" {
+		t.Errorf("port = %q, want 443", got)
+	}
+}
+
+func TestProfileLayers(t *testing.T) {
+	paths := writeConfigFiles(t,
+		"default.ini", "port=80\nport@production=443\nhost=a\n",
+		"site.ini", "port=8443\nhost@production=b\n")
+	c := NewConfigReader(WithProfile("production"))
+	if _, err := c.ReadLayered(paths...); err != nil {
+		t.Fatal(err)
+	}
+	// a later layer overrides the profile of an earlier one
+	if got, _ := c.GetString("port"); got != "8443" {
+		t.Errorf("port = %q, want 8443", got)
+	}
+	if got, _ := c.GetString("host"); got != "b" {
+		t.Errorf("host = %q, want b", got)
+	}
+
+	c = NewConfigReader(WithProfile("staging"))
+	c.ReadLayered(paths...)
+	if got, _ := c.GetString("host"); got != "a" {
+		t.Errorf("staging host = %q, want a", got)
+	}
+}
+
+func TestProfileSetAndSave(t *testing.T) {
+	t.Setenv(DefaultProfileEnv, "")
+	paths := writeConfigFiles(t, "app.ini", "port=8080\nport@production=443\n")
+	c := NewConfigReader(WithProfile("production"))
+	c.Read(paths[0])
+	c.Set("port", "9000")
+	if err := c.Save(); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(paths[0])
+	if got := string(data); got != "port=8080\nport@production=9000\n" {
+		t.Errorf("saved %q", got)
+	}
+	r := NewConfigReader(WithProfile("production"))
+	r.Read(paths[0])
+	if got, _ := r.GetString("port"); got != "9000" {
+		t.Errorf("port after reading again = %q, want 9000", got)
+	}
+
+	// without the profile the shared line is changed
+	c = NewConfigReader()
+	c.Read(paths[0])
+	c.Set("port", "81")
+	c.Save()
+	data, _ = os.ReadFile(paths[0])
+	if got := string(data); got != "port=81\nport@production=9000\n" {
+		t.Errorf("saved %q", got)
+	}
+
+	c = NewConfigReader(WithProfile("production"))
+	c.Read(paths[0])
+	c.Delete("port")
+	c.Save()
+	data, _ = os.ReadFile(paths[0])
+	if got := string(data); got != "" {
+		t.Errorf("saved after Delete %q", got)
+	}
+}
+
+func TestProfileLayerWithInclude(t *testing.T) {
+	t.Setenv(DefaultProfileEnv, "")
+	paths := writeConfigFiles(t, "inc.ini", "name=n\n", "app.ini", "port@production=443\n!include inc.ini\nport=8080\n")
+	text := "port@production=443\n!include " + paths[0] + "\nport=8080\n"
+
+	c := NewConfigReader(WithProfile("production"))
+	c.Read(paths[1])
+	r := NewConfigReader(WithProfile("production"))
+	if _, err := r.ReadReader(strings.NewReader(text), paths[1]); err != nil {
+		t.Fatal(err)
+	}
+	d := NewConfigReader(WithProfile("production"), WithDefaultsINI(text))
+	d.ReadReader(strings.NewReader(""), "empty.ini")
+	for name, conf := range map[string]*ConfigReader{"Read": c, "ReadReader": r, "WithDefaultsINI": d} {
+		if got, _ := conf.GetString("port"); got != "443" {
+			t.Errorf("%s: port = %q, want 443", name, got)
+		}
+		if got, _ := conf.GetString("name"); got != "n" {
+			t.Errorf("%s: name = %q, want n", name, got)
+		}
+	}
+}
+
+func TestProfileOnlyKnownNames(t *testing.T) {
+	t.Setenv(DefaultProfileEnv, "")
+	text := "alice@example.com=admin\nnotify=x\nport@staging=1\n[db@test]\nhost=h\n[users]\nbob@test=ro\n"
+
+	// '@' followed by a name never selected, declared or used in a header is part of the key
+	c := NewConfigReader()
+	if _, err := c.ReadReader(strings.NewReader(text), "app.ini"); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(c.Keys(), ","); got != "alice@example.com,notify,port@staging" {
+		t.Errorf("Keys() = %s", got)
+	}
+	if got := strings.Join(c.Profiles(), ","); got != "test" {
+		t.Errorf("Profiles() = %s", got)
+	}
+
+	c = NewConfigReader(WithProfiles("staging"))
+	c.ReadReader(strings.NewReader(text), "app.ini")
+	if got := strings.Join(c.Keys(), ","); got != "alice@example.com,notify" {
+		t.Errorf("declared: Keys() = %s", got)
+	}
+
+	c = NewConfigReader(WithProfile("test"))
+	c.ReadReader(strings.NewReader(text), "app.ini")
+	for key, want := range map[string]string{"alice@example.com": "admin", "db.host": "h", "users.bob": "ro"} {
+		if got, _ := c.GetString(key); got != want {
+			t.Errorf("selected: %s = %q, want %q", key, got, want)
+		}
+	}
+}
@@ -0,0 +1,209 @@
+package goutils
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+)
+
+/*
+
+config_ini_sample.go
+
+a commented sample ini generated from the struct filled by Unmarshal, with the
+'desc' and 'default' tags (or the default= option of the 'ini' tag):
+
+			type DBConfig struct {
+				Host string `ini:"host,required" desc:"database server"`
+				Port int    `ini:"port" default:"5432" desc:"database port"`
+			}
+
+			type AppConfig struct {
+				Name string   `ini:"name" desc:"service name"`
+				DB   DBConfig `ini:"database"`
+			}
+
+			goutils.WriteSampleINI(os.Stdout, AppConfig{})
+
+writes:
+
+			# sample configuration generated from AppConfig by goutils.WriteSampleINI
+
+			# service name
+			# type: string
+			#name=
+
+			[database]
+
+			# database server
+			# type: string, required
+			host=
+
+			# database port
+			# type: int, default: 5432
+			#port=5432
+
+required keys without default are left active and empty, the others are commented out
+
+*/
+
+// sampleKey is a key documented in a sample ini
+type sampleKey struct {
+	key        string // key inside its section
+	typ        string
+	desc       string
+	defValue   string
+	hasDefault bool
+	required   bool
+}
+
+// sampleSection is a block of a sample ini, "" for the keys outside sections
+type sampleSection struct {
+	name string
+	keys []sampleKey
+}
+
+// WriteSampleINI writes a commented sample ini with the keys of the struct (or pointer to struct) v
+func WriteSampleINI(w io.Writer, v interface{}) error {
+	rt := reflect.TypeOf(v)
+	if rt != nil && rt.Kind() == reflect.Ptr {
+		rt = rt.Elem()
+	}
+	if rt == nil || rt.Kind() != reflect.Struct {
+		return errors.New("goutils.WriteSampleINI: argument must be a struct or a pointer to struct")
+	}
+	sections := []*sampleSection{{name: ""}}
+	collectSampleKeys(rt, "", &sections)
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "# sample configuration generated from %s by goutils.WriteSampleINI\n", rt.Name())
+	for _, s := range sections {
+		if len(s.keys) == 0 {
+			continue
+		}
+		if s.name != "" {
+			fmt.Fprintf(bw, "\n[%s]\n", s.name)
+		}
+		for _, k := range s.keys {
+			bw.WriteString("\n")
+			for _, line := range strings.Split(k.desc, "\n") {
+				if line = strings.TrimSpace(line); line != "" {
+					fmt.Fprintf(bw, "# %s\n", line)
+				}
+			}
+			typ := "# type: " + k.typ
+			if k.required {
+				typ += ", required"
+			}
+			if k.hasDefault {
+				typ += ", default: " + k.defValue
+			}
+			bw.WriteString(typ + "\n")
+			if k.required && !k.hasDefault {
+				fmt.Fprintf(bw, "%s=\n", k.key)
+			} else {
+				fmt.Fprintf(bw, "#%s=%s\n", k.key, k.defValue)
+			}
+		}
+	}
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("goutils.WriteSampleINI: %v", err)
+	}
+	return nil
+}
+
+// SampleINI returns the sample ini written by WriteSampleINI
+func SampleINI(v interface{}) (string, error) {
+	var sb strings.Builder
+	if err := WriteSampleINI(&sb, v); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
+
+// collectSampleKeys adds the keys of the struct type st, read with prefix, to their sections,
+// walking the fields as Unmarshal does
+func collectSampleKeys(st reflect.Type, prefix string, sections *[]*sampleSection) {
+	for i := 0; i < st.NumField(); i++ {
+		sf := st.Field(i)
+		if sf.PkgPath != "" {
+			continue // unexported, not filled by Unmarshal
+		}
+		ft := sf.Type
+		tag, tagged := sf.Tag.Lookup("ini")
+		if !tagged {
+			if sf.Anonymous && ft.Kind() == reflect.Struct {
+				collectSampleKeys(ft, prefix, sections)
+			}
+			continue
+		}
+		t := parseIniTag(tag)
+		if t.key == "-" {
+			continue
+		}
+		if t.key == "" {
+			t.key = sf.Name
+		}
+		if ft.Kind() == reflect.Struct && ft != timeType {
+			collectSampleKeys(ft, prefix+t.key+".", sections)
+			continue
+		}
+		fieldDefault(sf, &t)
+
+		name := strings.TrimSuffix(prefix, ".")
+		var section *sampleSection
+		for _, s := range *sections {
+			if s.name == name {
+				section = s
+			}
+		}
+		if section == nil {
+			section = &sampleSection{name: name}
+			*sections = append(*sections, section)
+		}
+		section.keys = append(section.keys, sampleKey{
+			key:        t.key,
+			typ:        sampleTypeName(ft),
+			desc:       sf.Tag.Get("desc"),
+			defValue:   t.defValue,
+			hasDefault: t.hasDefault,
+			required:   t.required,
+		})
+	}
+}
+
+// fieldDefault takes the default of a field from its 'default' tag when the 'ini' tag has none
+func fieldDefault(sf reflect.StructField, t *iniTag) {
+	if t.hasDefault {
+		return
+	}
+	if def, ok := sf.Tag.Lookup("default"); ok {
+		t.defValue, t.hasDefault = def, true
+	}
+}
+
+// sampleTypeName describes the values accepted for a field type
+func sampleTypeName(ft reflect.Type) string {
+	switch ft {
+	case durationType:
+		return "duration (e.g. 30s, 5m)"
+	case timeType:
+		return "time (RFC3339)"
+	}
+	switch ft.Kind() {
+	case reflect.Bool:
+		return "bool"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return "int"
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "unsigned int"
+	case reflect.Float32, reflect.Float64:
+		return "float"
+	case reflect.Slice:
+		return "comma separated list of " + sampleTypeName(ft.Elem())
+	}
+	return ft.Kind().String()
+}
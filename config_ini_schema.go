@@ -0,0 +1,294 @@
+package goutils
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/*
+
+config_ini_schema.go
+
+validation of the configured items against a schema, at startup:
+
+			schema := goutils.ConfigSchema{
+				{Key: "name", Required: true},
+				{Key: "database.port", Type: goutils.ConfigTypeInt, Min: "1", Max: "65535", Default: "5432"},
+				{Key: "timeout", Type: goutils.ConfigTypeDuration, Max: "1m"},
+				{Key: "log_level", Allowed: []string{"debug", "info", "error"}},
+				{Key: "email", Pattern: `^[^@]+@[^@]+$`},
+			}
+			warnings, err := conf.Validate(schema)
+
+err is a *ConfigValidationError listing every violation with file and line,
+warnings report the keys not in the schema with a 'did you mean' suggestion
+
+*/
+
+// ConfigValueType is the type of a value declared in a ConfigSchema
+type ConfigValueType int
+
+// Types of the values in a ConfigSchema
+const (
+	ConfigTypeString ConfigValueType = iota
+	ConfigTypeInt
+	ConfigTypeUint
+	ConfigTypeFloat
+	ConfigTypeBool
+	ConfigTypeDuration
+	ConfigTypeByteSize
+)
+
+func (t ConfigValueType) String() string {
+	switch t {
+	case ConfigTypeString:
+		return "string"
+	case ConfigTypeInt:
+		return "int"
+	case ConfigTypeUint:
+		return "uint"
+	case ConfigTypeFloat:
+		return "float"
+	case ConfigTypeBool:
+		return "bool"
+	case ConfigTypeDuration:
+		return "duration"
+	case ConfigTypeByteSize:
+		return "bytesize"
+	}
+	return fmt.Sprintf("ConfigValueType(%d)", int(t))
+}
+
+// ConfigKeySpec declares a key of a ConfigSchema
+type ConfigKeySpec struct {
+	Key      string          // qualified item name, 'section.key' for keys in sections
+	Type     ConfigValueType // ConfigTypeString when not set
+	Required bool            // a missing required key without Default is a violation
+	Default  string          // value used by the program when the key is missing
+	Min      string          // lower limit written like a value of Type, numeric types only
+	Max      string          // upper limit written like a value of Type, numeric types only
+	Pattern  string          // regular expression the value must match
+	Allowed  []string        // when not empty the value must be one of these
+}
+
+// ConfigSchema lists the keys known by a program
+type ConfigSchema []ConfigKeySpec
+
+// ConfigViolation is a problem found by Validate
+type ConfigViolation struct {
+	Key     string
+	File    string // file the value was read from, or 'env NAME' for environment overrides
+	Line    int    // 0 when not read from a file
+	Message string
+}
+
+func (v ConfigViolation) Error() string {
+	if v.Line > 0 {
+		return fmt.Sprintf("%s:%d: key '%s': %s", v.File, v.Line, v.Key, v.Message)
+	}
+	if v.File != "" {
+		return fmt.Sprintf("%s: key '%s': %s", v.File, v.Key, v.Message)
+	}
+	return fmt.Sprintf("key '%s': %s", v.Key, v.Message)
+}
+
+// ConfigValidationError is returned by Validate listing every violation
+type ConfigValidationError struct {
+	Violations []ConfigViolation
+}
+
+func (e *ConfigValidationError) Error() string {
+	msgs := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		msgs[i] = v.Error()
+	}
+	return fmt.Sprintf("goutils.ConfigReader.Validate: %d violations: %s", len(e.Violations), strings.Join(msgs, "; "))
+}
+
+// Validate checks the configured items against schema.
+// All the violations are returned together in a *ConfigValidationError,
+// the keys not declared in schema are returned as warnings
+func (c *ConfigReader) Validate(schema ConfigSchema) (warnings []ConfigViolation, err error) {
+	c = c.Snapshot() // every key is checked against the same version of the items
+	var violations []ConfigViolation
+	known := make(map[string]bool, len(schema))
+
+	for _, spec := range schema {
+		known[spec.Key] = true
+		value, source, found := c.lookupSource(spec.Key)
+		if !found {
+			if spec.Required && spec.Default == "" {
+				violations = append(violations, ConfigViolation{Key: spec.Key, File: c.ConfigFilePath(), Message: "required key is missing"})
+			}
+			continue
+		}
+		if msg := spec.check(value, c.IsSecret(spec.Key)); msg != "" {
+			violations = append(violations, c.violation(spec.Key, source, msg))
+		}
+	}
+
+	items := c.currentItems()
+	var unknown []string
+	for k := range items {
+		if !known[k] {
+			unknown = append(unknown, k)
+		}
+	}
+	sort.Strings(unknown)
+	for _, k := range unknown {
+		msg := "unknown key"
+		if suggestion := closestKey(k, schema); suggestion != "" {
+			msg += fmt.Sprintf(", did you mean '%s'?", suggestion)
+		}
+		warnings = append(warnings, c.violation(k, ConfigSourceFile, msg))
+	}
+
+	if len(violations) > 0 {
+		err = &ConfigValidationError{Violations: violations}
+	}
+	return
+}
+
+// ConfigFilePath returns the path of the config file read, the last one for layered reads
+func (c *ConfigReader) ConfigFilePath() string {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.configFilePath
+}
+
+// violation returns a ConfigViolation with the position the value of key comes from
+func (c *ConfigReader) violation(key string, source ConfigSource, msg string) ConfigViolation {
+	v := ConfigViolation{Key: key, Message: msg}
+	if source == ConfigSourceEnv {
+		v.File = "env " + c.EnvName(key)
+		return v
+	}
+	c.lock.RLock()
+	origin, ok := c.origins[key]
+	c.lock.RUnlock()
+	if ok {
+		v.File, v.Line = origin.path, origin.line
+	}
+	return v
+}
+
+// check returns why value does not satisfy the spec, an empty string when it does.
+// The value of a secret key is not shown
+func (spec ConfigKeySpec) check(value string, secret bool) string {
+	shown := value
+	if secret {
+		shown = maskedValue
+	}
+	n, err := spec.Type.number(value)
+	if err != nil {
+		if secret {
+			return fmt.Sprintf("value '%s' is not a valid %s", shown, spec.Type)
+		}
+		return fmt.Sprintf("value '%s' is not a valid %s: %v", shown, spec.Type, err)
+	}
+	for _, limit := range []struct {
+		bound string
+		below bool
+	}{{spec.Min, true}, {spec.Max, false}} {
+		if limit.bound == "" {
+			continue
+		}
+		b, err := spec.Type.number(limit.bound)
+		if err != nil {
+			return fmt.Sprintf("invalid schema limit '%s' for a %s", limit.bound, spec.Type)
+		}
+		if limit.below && n < b {
+			return fmt.Sprintf("value '%s' is less than the minimum %s", shown, limit.bound)
+		}
+		if !limit.below && n > b {
+			return fmt.Sprintf("value '%s' is greater than the maximum %s", shown, limit.bound)
+		}
+	}
+	if spec.Pattern != "" {
+		re, err := regexp.Compile(spec.Pattern)
+		if err != nil {
+			return fmt.Sprintf("invalid schema pattern '%s': %v", spec.Pattern, err)
+		}
+		if !re.MatchString(value) {
+			return fmt.Sprintf("value '%s' does not match '%s'", shown, spec.Pattern)
+		}
+	}
+	if len(spec.Allowed) > 0 && !containsString(spec.Allowed, value) {
+		return fmt.Sprintf("value '%s' is not one of %s", shown, strings.Join(spec.Allowed, ", "))
+	}
+	return ""
+}
+
+// number converts a value of type t to a number comparable with Min and Max
+func (t ConfigValueType) number(value string) (float64, error) {
+	s := strings.TrimSpace(value)
+	switch t {
+	case ConfigTypeInt:
+		i, err := strconv.ParseInt(s, 10, 64)
+		return float64(i), err
+	case ConfigTypeUint:
+		u, err := strconv.ParseUint(s, 10, 64)
+		return float64(u), err
+	case ConfigTypeFloat:
+		return strconv.ParseFloat(s, 64)
+	case ConfigTypeBool:
+		_, err := strconv.ParseBool(s)
+		return 0, err
+	case ConfigTypeDuration:
+		d, err := time.ParseDuration(s)
+		return float64(d), err
+	case ConfigTypeByteSize:
+		b, err := ParseByteSize(s)
+		return float64(b), err
+	}
+	return 0, nil
+}
+
+// closestKey returns the schema key most similar to key, empty when none is close enough:
+// at most 2 edits, or one every 3 characters for long keys
+func closestKey(key string, schema ConfigSchema) (best string) {
+	maxDistance := len(key) / 3
+	if maxDistance < 2 {
+		maxDistance = 2
+	}
+	bestDistance := maxDistance + 1
+	for _, spec := range schema {
+		if d := editDistance(key, spec.Key); d < bestDistance {
+			best, bestDistance = spec.Key, d
+		}
+	}
+	return
+}
+
+// editDistance returns the Levenshtein distance between a and b
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = minInt(minInt(prev[j]+1, cur[j-1]+1), prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,55 @@
+package goutils
+
+import (
+	"errors"
+)
+
+/*
+
+config_ini_snapshot.go
+
+a ConfigReader can be read by many goroutines while Watch or Read replace its items,
+every getter sees either the old or the new items, never a mix.
+To use the same version of the configuration for a whole request take a snapshot:
+
+			snap := conf.Snapshot()
+			host, _ := snap.GetString("database.host")
+			port, _ := snap.GetInt("database.port") // same version as host even if a reload happened
+
+*/
+
+var errReadOnly = errors.New("read only snapshot")
+
+// Snapshot returns a read only copy of the current configuration, with all the getters
+// of ConfigReader. Later reloads and Set calls on c do not change it, while Read, Set,
+// Delete and Watch on the snapshot fail.
+// Environment overrides are still looked up when a value is read
+func (c *ConfigReader) Snapshot() *ConfigReader {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	// items, lines and the other slices and maps are never modified once installed,
+	// Set and Delete replace them, so they can be shared
+	return &ConfigReader{
+		configFilePath: c.configFilePath,
+		savePath:       c.savePath,
+		items:          c.items,
+		sections:       c.sections,
+		lines:          c.lines,
+		origins:        c.origins,
+		history:        c.history,
+		warnings:       c.warnings,
+		files:          c.files,
+		readOnly:       true,
+		profile:        c.profile,
+		activeProfile:  c.activeProfile,
+		profiles:       c.profiles,
+		format:         c.format,
+		noLines:        c.noLines,
+		configOptions:  c.configOptions,
+	}
+}
+
+// IsSnapshot tells whether c is a read only snapshot
+func (c *ConfigReader) IsSnapshot() bool {
+	return c.readOnly
+}
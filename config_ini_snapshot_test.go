@@ -0,0 +1,103 @@
+package goutils
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+)
+
+// run with -race: the getters, Snapshot and the writers share the same ConfigReader
+
+func TestConcurrentReadersAndWriters(t *testing.T) {
+	t.Setenv(DefaultProfileEnv, "")
+	paths := writeConfigFiles(t, "app.ini", "name=a\nport=80\nport@production=443\n[db]\nhost=h\n")
+	c := NewConfigReader()
+	if _, err := c.Read(paths[0]); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go c.Watch(ctx, time.Millisecond)
+
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+	loop := func(fn func(i int)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; ; i++ {
+				select {
+				case <-stop:
+					return
+				default:
+					fn(i)
+				}
+			}
+		}()
+	}
+
+	loop(func(i int) {
+		c.GetString("name")
+		c.GetIntOr("port", 0)
+		c.Section("db").GetStringOr("host", "")
+		c.Keys()
+		c.Explain("port")
+		c.ToMap()
+	})
+	loop(func(i int) {
+		s := c.Snapshot()
+		s.GetString("name")
+		s.Keys()
+	})
+	loop(func(i int) {
+		c.Set("name", fmt.Sprint("n", i))
+		c.Set(fmt.Sprint("db.k", i%5), "v")
+		c.Delete(fmt.Sprint("db.k", (i+2)%5))
+	})
+	loop(func(i int) {
+		if i%2 == 0 {
+			c.SelectProfile("production")
+		} else {
+			c.SelectProfile("")
+		}
+	})
+	loop(func(i int) {
+		c.Read(paths[0])
+		os.WriteFile(paths[0], []byte(fmt.Sprintf("name=a%d\nport=80\n[db]\nhost=h\n", i)), 0644)
+	})
+
+	time.Sleep(200 * time.Millisecond)
+	close(stop)
+	wg.Wait()
+}
+
+func TestSnapshotKeepsValues(t *testing.T) {
+	t.Setenv(DefaultProfileEnv, "")
+	paths := writeConfigFiles(t, "app.ini", "name=a\nport=80\n")
+	c := NewConfigReader()
+	c.Read(paths[0])
+	s := c.Snapshot()
+
+	os.WriteFile(paths[0], []byte("name=b\n"), 0644)
+	c.Read(paths[0])
+	c.Set("port", "81")
+
+	if got, _ := c.GetString("name"); got != "b" {
+		t.Errorf("reader name = %q, want b", got)
+	}
+	if got, _ := s.GetString("name"); got != "a" {
+		t.Errorf("snapshot name = %q, want a", got)
+	}
+	if got, _ := s.GetString("port"); got != "80" {
+		t.Errorf("snapshot port = %q, want 80", got)
+	}
+	if _, err := s.Read(paths[0]); err == nil {
+		t.Error("Read on a snapshot did not fail")
+	}
+	if got, _ := s.GetString("name"); got != "a" {
+		t.Errorf("snapshot name after Read = %q, want a", got)
+	}
+}
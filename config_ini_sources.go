@@ -0,0 +1,130 @@
+/*
+
+config_ini_sources.go
+
+configuration read from other sources than a file path:
+
+			conf.ReadReader(resp.Body, "https://config.example.com/app.ini")
+			conf.ReadReader(strings.NewReader("name=test\n"), "test")
+
+			//go:embed conf
+			var confFS embed.FS
+			conf.ReadFS(confFS, "conf/app.ini")
+
+defaults used when no file sets a key, with the lowest priority:
+
+			//go:embed default.ini
+			var defaultINI string
+
+			conf := goutils.NewConfigReader(
+				goutils.WithDefaultsINI(defaultINI),
+				goutils.WithDefaults(map[string]string{"database.port": "5432"}),
+			)
+
+WithDefaultsINI is read after WithDefaults, so its values win; includes in a ReadFS
+file are resolved inside the same fs.FS, includes in a ReadReader text are resolved
+from the folder of its name
+
+*/
+
+package goutils
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"sort"
+	"strings"
+)
+
+// ReadReader reads and parses a configuration from r, name is used in messages and errors
+// (it is not called ReadFrom to not clash with io.ReaderFrom). Save is not available, use SaveAs
+func (c *ConfigReader) ReadReader(r io.Reader, name string) (numItemsFound int, err error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		c.logf("goutils.ConfigReader.ReadReader(%s) read error: %s", name, err)
+		return
+	}
+	return c.load(&parsedConfig{path: name}, func() (*parsedConfig, error) {
+		l, err := c.newLoader(nil)
+		if err != nil {
+			return nil, err
+		}
+		l.pc.path = name
+		if err = l.readLines(bytes.NewReader(data), name, "", true, ConfigSourceFile); err != nil {
+			return nil, err
+		}
+		return l.finish()
+	})
+}
+
+// ReadFS reads and parses a configuration file of fsys, e.g. an embed.FS.
+// Save is not available, use SaveAs
+func (c *ConfigReader) ReadFS(fsys fs.FS, configPath string) (numItemsFound int, err error) {
+	failed := &parsedConfig{path: configPath, files: []watchedFile{{path: configPath, fsys: fsys}}}
+	return c.load(failed, func() (*parsedConfig, error) {
+		l, err := c.newLoader(fsys)
+		if err != nil {
+			return nil, err
+		}
+		if err = l.readFile(configPath, "", true); err != nil {
+			return nil, err
+		}
+		l.pc.path = configPath
+		return l.finish()
+	})
+}
+
+// WithDefaults sets values, by qualified item name, used when no file sets them
+func WithDefaults(defaults map[string]string) ConfigOption {
+	return func(c *ConfigReader) {
+		if c.defaults == nil {
+			c.defaults = make(map[string]string)
+		}
+		for k, v := range defaults {
+			c.defaults[k] = v
+		}
+	}
+}
+
+// WithDefaultsINI sets values used when no file sets them, written in the syntax of the files
+func WithDefaultsINI(text string) ConfigOption {
+	return func(c *ConfigReader) {
+		c.defaultsINI = text
+	}
+}
+
+// readDefaults loads the defaults as the first layer of items
+func (l *configLoader) readDefaults() error {
+	keys := make([]string, 0, len(l.c.defaults))
+	for k := range l.c.defaults {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		l.define(k, l.c.defaults[k], itemOrigin{source: ConfigSourceDefault, path: "defaults"})
+		if section := sectionOfKey(k); section != "" && !containsString(l.pc.sections, section) {
+			l.pc.sections = append(l.pc.sections, section)
+		}
+	}
+	l.applyLayerProfile()
+	if l.c.defaultsINI == "" {
+		return nil
+	}
+	if err := l.readLines(strings.NewReader(l.c.defaultsINI), "defaults", "", false, ConfigSourceDefault); err != nil {
+		return fmt.Errorf("goutils.ConfigReader defaults: %v", err)
+	}
+	return nil
+}
+
+// Defaults returns the Default of the keys of the schema that have one, to be used with WithDefaults
+func (schema ConfigSchema) Defaults() map[string]string {
+	defaults := make(map[string]string)
+	for _, spec := range schema {
+		if spec.Default != "" {
+			defaults[spec.Key] = spec.Default
+		}
+	}
+	return defaults
+}
@@ -0,0 +1,179 @@
+package goutils
+
+import (
+	"strings"
+)
+
+/*
+
+config_ini_syntax.go
+
+ConfigReader reads files with the original syntax unless ParserModeStandard is set:
+
+			conf := goutils.NewConfigReader(goutils.WithParserMode(goutils.ParserModeStandard))
+
+ParserModeStandard accepts:
+
+			; comment
+			# comment
+			key = value                 -> 'key', 'value'
+			key: value                  -> 'key', 'value'
+			name = "  spaced ; #  "     -> '  spaced ; #  '
+			path = 'C:\\temp'           -> 'C:\temp'
+			msg = "line 1\nline 2"      -> escapes \n \t \r \\ \" \' in quoted values
+			port = 80 ; inline comment  -> '80', comments start with ';' or '#' after a space
+			hosts = a, b, \
+			        c                   -> 'a, b, c', a final '\' continues on the next line
+
+*/
+
+// ParserMode is the syntax of the files read by ConfigReader
+type ParserMode int
+
+// Syntaxes of the files read by ConfigReader
+const (
+	// ParserModeLegacy: '#' comments at column 0 only, key and value split on the
+	// first '=' keeping the spaces around them
+	ParserModeLegacy ParserMode = iota
+	// ParserModeStandard: trimmed keys and values, ';' and '#' comments, quoted
+	// values with escapes, line continuations and 'key: value'
+	ParserModeStandard
+)
+
+// WithParserMode sets the syntax of the files read
+func WithParserMode(mode ParserMode) ConfigOption {
+	return func(c *ConfigReader) {
+		c.parserMode = mode
+	}
+}
+
+// parseStandardLine decodes a line with the ParserModeStandard syntax
+func parseStandardLine(t string) parsedLine {
+	s := strings.TrimSpace(t)
+	if s == "" || s[0] == '#' || s[0] == ';' {
+		return parsedLine{kind: lineBlank}
+	}
+	if strings.HasPrefix(s, "!include ") {
+		return parsedLine{kind: lineInclude, name: strings.TrimSpace(stripInlineComment(s[len("!include "):]))}
+	}
+	column := strings.Index(t, s[:1]) + 1
+	if s[0] == '[' {
+		if name, ok := parseSectionHeader(stripInlineComment(s)); ok {
+			return parsedLine{kind: lineSection, name: name}
+		}
+		return parsedLine{kind: lineInvalid, column: column, reason: "invalid section header"}
+	}
+
+	sep := strings.IndexAny(t, "=:")
+	if sep < 0 {
+		return parsedLine{kind: lineInvalid, column: len(t) + 1, reason: "missing '=' or ':'"}
+	}
+	key := strings.TrimSpace(t[:sep])
+	if key == "" {
+		return parsedLine{kind: lineInvalid, column: sep + 1, reason: "empty key"}
+	}
+
+	start := sep + 1
+	for start < len(t) && (t[start] == ' ' || t[start] == '\t') {
+		start++
+	}
+	value, end, bad := parseStandardValue(t, start)
+	if bad.reason != "" {
+		bad.kind = lineInvalid
+		return bad
+	}
+	if key == "include" {
+		return parsedLine{kind: lineInclude, name: value}
+	}
+	return parsedLine{kind: lineKeyValue, name: key, value: value, valueStart: start, valueEnd: end, column: column}
+}
+
+// parseStandardValue decodes the value starting at t[start], returning it with
+// the position where it ends (before spaces and inline comment).
+// When the value is malformed bad holds the column and the reason
+func parseStandardValue(t string, start int) (value string, end int, bad parsedLine) {
+	if start < len(t) && (t[start] == '"' || t[start] == '\'') {
+		quote := t[start]
+		var b strings.Builder
+		i := start + 1
+		for ; i < len(t) && t[i] != quote; i++ {
+			if t[i] == '\\' && i+1 < len(t) {
+				i++
+				b.WriteByte(unescapeByte(t[i]))
+				continue
+			}
+			b.WriteByte(t[i])
+		}
+		if i >= len(t) {
+			bad = parsedLine{column: start + 1, reason: "unterminated quoted value"}
+			return
+		}
+		end = i + 1
+		if rest := strings.TrimSpace(t[end:]); rest != "" && rest[0] != ';' && rest[0] != '#' {
+			bad = parsedLine{column: end + 1, reason: "unexpected text after quoted value"}
+			return
+		}
+		return b.String(), end, bad
+	}
+
+	end = len(t)
+	for i := start; i < len(t); i++ {
+		if (t[i] == ';' || t[i] == '#') && (i == start || t[i-1] == ' ' || t[i-1] == '\t') {
+			end = i
+			break
+		}
+	}
+	for end > start && (t[end-1] == ' ' || t[end-1] == '\t') {
+		end--
+	}
+	return t[start:end], end, bad
+}
+
+func unescapeByte(b byte) byte {
+	switch b {
+	case 'n':
+		return '\n'
+	case 't':
+		return '\t'
+	case 'r':
+		return '\r'
+	}
+	return b
+}
+
+// stripInlineComment removes a ';' or '#' comment following a space
+func stripInlineComment(s string) string {
+	for i := 1; i < len(s); i++ {
+		if (s[i] == ';' || s[i] == '#') && (s[i-1] == ' ' || s[i-1] == '\t') {
+			return strings.TrimSpace(s[:i])
+		}
+	}
+	return s
+}
+
+// continuesLine tells whether a line ends with a '\' continuing on the next line
+func continuesLine(t string) bool {
+	s := strings.TrimSpace(t)
+	if s == "" || s[0] == '#' || s[0] == ';' {
+		return false
+	}
+	return strings.HasSuffix(s, "\\")
+}
+
+// joinContinuation joins a continued line with the next one
+func joinContinuation(t, next string) string {
+	t = strings.TrimRight(t, " \t")
+	return t[:len(t)-1] + strings.TrimLeft(next, " \t")
+}
+
+// formatValue returns value as written by Set in a file of the configured syntax
+func (c *ConfigReader) formatValue(value string) string {
+	if c.parserMode != ParserModeStandard {
+		return value
+	}
+	if value == strings.TrimSpace(value) && !strings.ContainsAny(value, "\"'\\;#\n\r\t") {
+		return value
+	}
+	r := strings.NewReplacer("\\", "\\\\", "\"", "\\\"", "\n", "\\n", "\r", "\\r", "\t", "\\t")
+	return "\"" + r.Replace(value) + "\""
+}
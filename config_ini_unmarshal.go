@@ -0,0 +1,238 @@
+/*
+
+config_ini_unmarshal.go
+
+ConfigReader.Unmarshal fills a struct from the configured items using 'ini' tags:
+
+			type DBConfig struct {
+				Host    string        `ini:"host,required"`
+				Port    int           `ini:"port,default=5432"`
+				Timeout time.Duration `ini:"timeout,default=30s"`
+			}
+
+			type AppConfig struct {
+				Name  string   `ini:"name"`
+				Hosts []string `ini:"hosts"`
+				DB    DBConfig `ini:"database"` // keys of section [database] or 'database.xxx'
+			}
+
+			var cfg AppConfig
+			err := conf.Unmarshal(&cfg)
+
+Fields without an 'ini' tag (or tagged "-") are skipped, embedded structs without tag
+are filled with the same prefix of the embedding struct.
+A default can also be given with a separate tag: `ini:"port" default:"5432"`.
+Slices are read as comma separated lists.
+
+*/
+
+package goutils
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// UnmarshalFieldError describes a key that Unmarshal could not use
+type UnmarshalFieldError struct {
+	Key     string // qualified item name
+	Field   string // struct field path, e.g. 'AppConfig.DB.Port'
+	Value   string // raw value, empty when Missing, masked for secret keys
+	Missing bool   // true when a required key was not found
+	Err     error  // conversion error when not Missing
+}
+
+func (e UnmarshalFieldError) Error() string {
+	if e.Missing {
+		return fmt.Sprintf("missing required key '%s' (%s)", e.Key, e.Field)
+	}
+	return fmt.Sprintf("key '%s' (%s) value '%s': %v", e.Key, e.Field, e.Value, e.Err)
+}
+
+// UnmarshalError is returned by Unmarshal listing every key that could not be used
+type UnmarshalError struct {
+	Fields []UnmarshalFieldError
+}
+
+func (e *UnmarshalError) Error() string {
+	msgs := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		msgs[i] = f.Error()
+	}
+	return fmt.Sprintf("goutils.ConfigReader.Unmarshal: %d invalid keys: %s", len(e.Fields), strings.Join(msgs, "; "))
+}
+
+// iniTag is the parsed content of an `ini:"key,default=x,required"` tag
+type iniTag struct {
+	key        string
+	defValue   string
+	hasDefault bool
+	required   bool
+}
+
+// parseIniTag parses an 'ini' tag, everything following 'default=' up to the
+// next known option belongs to the default so that list defaults can contain commas
+func parseIniTag(tag string) (t iniTag) {
+	parts := strings.Split(tag, ",")
+	t.key = strings.TrimSpace(parts[0])
+	inDefault := false
+	for _, p := range parts[1:] {
+		opt := strings.TrimSpace(p)
+		switch {
+		case opt == "required":
+			t.required = true
+			inDefault = false
+		case strings.HasPrefix(opt, "default="):
+			t.defValue = strings.TrimPrefix(strings.TrimLeft(p, " "), "default=")
+			t.hasDefault = true
+			inDefault = true
+		case inDefault:
+			t.defValue += "," + p
+		}
+	}
+	return
+}
+
+var (
+	durationType = reflect.TypeOf(time.Duration(0))
+	timeType     = reflect.TypeOf(time.Time{})
+)
+
+// Unmarshal fills the struct pointed by v with the configured items.
+// All the missing required keys and the values that cannot be converted are
+// reported together in a single *UnmarshalError
+func (c *ConfigReader) Unmarshal(v interface{}) error {
+	return c.unmarshal(v, "")
+}
+
+// unmarshal fills v with the items whose name starts with prefix
+func (c *ConfigReader) unmarshal(v interface{}, prefix string) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return errors.New("goutils.ConfigReader.Unmarshal: argument must be a non nil pointer to struct")
+	}
+	ue := &UnmarshalError{}
+	// every field is filled from the same version of the items
+	c.Snapshot().unmarshalStruct(rv.Elem(), prefix, rv.Elem().Type().Name(), ue)
+	if len(ue.Fields) > 0 {
+		return ue
+	}
+	return nil
+}
+
+func (c *ConfigReader) unmarshalStruct(sv reflect.Value, prefix, path string, ue *UnmarshalError) {
+	st := sv.Type()
+	for i := 0; i < st.NumField(); i++ {
+		sf := st.Field(i)
+		fv := sv.Field(i)
+		if !fv.CanSet() {
+			continue
+		}
+		fieldPath := path + "." + sf.Name
+		tag, tagged := sf.Tag.Lookup("ini")
+		if !tagged {
+			if sf.Anonymous && fv.Kind() == reflect.Struct {
+				c.unmarshalStruct(fv, prefix, fieldPath, ue)
+			}
+			continue
+		}
+		t := parseIniTag(tag)
+		if t.key == "-" {
+			continue
+		}
+		if t.key == "" {
+			t.key = sf.Name
+		}
+		key := prefix + t.key
+
+		if fv.Kind() == reflect.Struct && fv.Type() != timeType {
+			c.unmarshalStruct(fv, key+".", fieldPath, ue)
+			continue
+		}
+		fieldDefault(sf, &t)
+
+		value, found := c.lookup(key)
+		if !found {
+			switch {
+			case t.hasDefault:
+				value = t.defValue
+			case t.required:
+				ue.Fields = append(ue.Fields, UnmarshalFieldError{Key: key, Field: fieldPath, Missing: true})
+				continue
+			default:
+				continue
+			}
+		}
+		if err := setFieldFromString(fv, value); err != nil {
+			ue.Fields = append(ue.Fields, UnmarshalFieldError{Key: key, Field: fieldPath, Value: c.maskValue(key, value), Err: c.maskError(key, err)})
+		}
+	}
+}
+
+// setFieldFromString converts s to the type of fv and stores it
+func setFieldFromString(fv reflect.Value, s string) error {
+	switch fv.Type() {
+	case durationType:
+		d, err := time.ParseDuration(strings.TrimSpace(s))
+		if err != nil {
+			return err
+		}
+		fv.SetInt(int64(d))
+		return nil
+	case timeType:
+		tm, err := time.Parse(time.RFC3339, strings.TrimSpace(s))
+		if err != nil {
+			return err
+		}
+		fv.Set(reflect.ValueOf(tm))
+		return nil
+	}
+
+	switch fv.Kind() {
+	case reflect.String:
+		fv.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(strings.TrimSpace(s))
+		if err != nil {
+			return err
+		}
+		fv.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(strings.TrimSpace(s), 10, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(strings.TrimSpace(s), 10, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(strings.TrimSpace(s), fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetFloat(f)
+	case reflect.Slice:
+		var parts []string
+		if strings.TrimSpace(s) != "" {
+			parts = strings.Split(s, ",")
+		}
+		sl := reflect.MakeSlice(fv.Type(), len(parts), len(parts))
+		for i, p := range parts {
+			if err := setFieldFromString(sl.Index(i), strings.TrimSpace(p)); err != nil {
+				return fmt.Errorf("element %d: %v", i, err)
+			}
+		}
+		fv.Set(sl)
+	default:
+		return fmt.Errorf("unsupported field type %s", fv.Type())
+	}
+	return nil
+}
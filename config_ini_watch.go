@@ -0,0 +1,195 @@
+package goutils
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"time"
+)
+
+/*
+
+config_ini_watch.go
+
+hot reload of the file read by ConfigReader:
+
+			conf.OnChange(func(old, new map[string]string) {
+				log.Printf("config changed: old %v new %v", old, new)
+			})
+			conf.OnReloadError(func(err error) {
+				log.Printf("config not reloaded: %v", err)
+			})
+			go conf.Watch(ctx, 5*time.Second)
+
+the files read are polled for changes of modification time or size, when one
+changes they are parsed again and the items are replaced at once; when parsing
+fails the previous items are kept
+
+*/
+
+// OnChange registers a function called by Watch after a reload changed some items.
+// old holds the previous value of the changed and removed keys,
+// new holds the value of the changed and added keys
+func (c *ConfigReader) OnChange(fn func(old, new map[string]string)) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.onChange = append(c.onChange, fn)
+}
+
+// OnReloadError registers a function called by Watch when the changed file
+// cannot be read; the previous items are still in use
+func (c *ConfigReader) OnReloadError(fn func(err error)) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.onReloadError = append(c.onReloadError, fn)
+}
+
+// Watch polls the files read every interval and reloads them when one changes,
+// it blocks until ctx is done and returns ctx.Err()
+func (c *ConfigReader) Watch(ctx context.Context, interval time.Duration) error {
+	if c.readOnly {
+		return errors.New("goutils.ConfigReader.Watch: " + errReadOnly.Error())
+	}
+	c.lock.RLock()
+	reload := c.reload
+	c.lock.RUnlock()
+	if reload == nil {
+		return errors.New("goutils.ConfigReader.Watch: no config file read")
+	}
+	if interval <= 0 {
+		return errors.New("goutils.ConfigReader.Watch: interval must be positive")
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	lastErr := ""
+	var failed []watchedFile // stat of files that could not be parsed, not retried until they change
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			var err error
+			failed, err = c.reloadIfChanged(failed)
+			if err == nil {
+				lastErr = ""
+			} else if err.Error() != lastErr {
+				// the same error is reported once until the files change again
+				lastErr = err.Error()
+				c.reportReloadError(err)
+			}
+		}
+	}
+}
+
+// reloadIfChanged reads the files again when the modification time or size of one changed,
+// it returns the stat of the files when parsing failed
+func (c *ConfigReader) reloadIfChanged(failed []watchedFile) ([]watchedFile, error) {
+	c.lock.RLock()
+	files, reload := c.files, c.reload
+	c.lock.RUnlock()
+
+	current := statFiles(files)
+	if sameFileStates(current, files) {
+		return nil, nil
+	}
+	if failed != nil && sameFileStates(current, failed) {
+		return failed, nil
+	}
+
+	pc, err := reload()
+	if err != nil {
+		return current, err
+	}
+	before := c.Snapshot()
+	previous := c.install(pc, reload)
+	c.logf("goutils.ConfigReader.Watch(%s) reloaded %d items", pc.path, len(pc.items))
+	c.notifyChange(before, previous, pc.items)
+	return nil, nil
+}
+
+// notifyChange calls the OnChange and OnDiff functions when a reload changed some items,
+// before is a snapshot taken before the reload
+func (c *ConfigReader) notifyChange(before *ConfigReader, previous, current map[string]string) {
+	oldDiff, newDiff := diffItemMaps(previous, current)
+	if len(oldDiff) == 0 && len(newDiff) == 0 {
+		return
+	}
+	c.lock.RLock()
+	callbacks := c.onChange
+	diffCallbacks := c.onDiff
+	c.lock.RUnlock()
+	for _, fn := range callbacks {
+		fn(oldDiff, newDiff)
+	}
+	if len(diffCallbacks) > 0 {
+		diff := Diff(before, c)
+		for _, fn := range diffCallbacks {
+			fn(diff)
+		}
+	}
+}
+
+// statFiles returns the current stat of files, nil for the missing ones
+func statFiles(files []watchedFile) []watchedFile {
+	current := make([]watchedFile, len(files))
+	for i, f := range files {
+		current[i] = watchedFile{path: f.path, fsys: f.fsys}
+		if f.fsys != nil {
+			current[i].info, _ = fs.Stat(f.fsys, f.path)
+		} else {
+			current[i].info, _ = os.Stat(f.path)
+		}
+	}
+	return current
+}
+
+// sameFileStates tells whether two lists of files have the same modification times and sizes
+func sameFileStates(a, b []watchedFile) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		ai, bi := a[i].info, b[i].info
+		if ai == nil || bi == nil {
+			if ai != bi {
+				return false
+			}
+			continue
+		}
+		if !ai.ModTime().Equal(bi.ModTime()) || ai.Size() != bi.Size() {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *ConfigReader) reportReloadError(err error) {
+	c.logf("goutils.ConfigReader.Watch reload error, keeping previous items: %s", err)
+	c.lock.RLock()
+	callbacks := c.onReloadError
+	c.lock.RUnlock()
+	for _, fn := range callbacks {
+		fn(err)
+	}
+}
+
+// diffItemMaps returns the previous values of the changed or removed keys
+// and the new values of the changed or added keys
+func diffItemMaps(previous, current map[string]string) (oldDiff, newDiff map[string]string) {
+	oldDiff = make(map[string]string)
+	newDiff = make(map[string]string)
+	for k, v := range previous {
+		if nv, ok := current[k]; !ok || nv != v {
+			oldDiff[k] = v
+		}
+	}
+	for k, v := range current {
+		if ov, ok := previous[k]; !ok || ov != v {
+			newDiff[k] = v
+		}
+	}
+	return
+}
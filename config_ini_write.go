@@ -0,0 +1,278 @@
+package goutils
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+/*
+
+config_ini_write.go
+
+ConfigReader keeps the lines of the file it read so that single keys can be changed
+without losing comments, blank lines and key order:
+
+			conf.Read("config.ini")
+			err := conf.Set("database.port", "5433")
+			conf.Delete("obsolete_key")
+			err = conf.Save()
+
+only the lines of the changed keys are rewritten; a new key is added after the last
+key of its section (or of the keys before any section when no section matches).
+Set stores the value as given, ${...} references are expanded on the next Read
+
+*/
+
+// Set changes the value of an item, adding it when it does not exist. In the legacy syntax
+// a value cannot hold line ends, that would add lines to the file. The value of an item
+// read encrypted is written encrypted with the decryption key, Set fails without the key
+func (c *ConfigReader) Set(itemName, value string) error {
+	if c.readOnly {
+		c.logf("goutils.ConfigReader.Set(%s) failed: %s", itemName, errReadOnly)
+		return fmt.Errorf("goutils.ConfigReader.Set(%s): %s", itemName, errReadOnly)
+	}
+	if c.parserMode != ParserModeStandard && strings.ContainsAny(value, "\n\r") {
+		return fmt.Errorf("goutils.ConfigReader.Set(%s): the value holds a line end, not allowed in the legacy syntax", itemName)
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	// a value supplied by the profile is changed on the line of the profile,
+	// otherwise on the shared line
+	profile := c.origins[itemName].profile
+	at := lastLineSetting(c.lines, itemName, profile)
+	if at < 0 {
+		profile = ""
+		at = lastLineSetting(c.lines, itemName, "")
+	}
+
+	// a value read encrypted is written encrypted
+	encrypted := c.origins[itemName].encrypted
+	if at >= 0 {
+		l := c.lines[at]
+		encrypted = encrypted || IsEncryptedValue(strings.Trim(l.text[l.valueStart:l.valueEnd], "\"' \t"))
+	}
+	written := c.formatValue(value)
+	if encrypted {
+		key, err := c.loadDecryptionKey()
+		var ciphertext string
+		if err == nil {
+			ciphertext, err = EncryptConfigValue(key, value)
+		}
+		if err != nil {
+			return fmt.Errorf("goutils.ConfigReader.Set(%s): the value is encrypted: %w", itemName, err)
+		}
+		written = c.formatValue(ciphertext)
+	}
+
+	items := make(map[string]string, len(c.items)+1)
+	for k, v := range c.items {
+		items[k] = v
+	}
+	items[itemName] = value
+	c.items = items
+	c.recordSet(itemName, value, profile, encrypted)
+
+	lines := append([]configLine(nil), c.lines...)
+	if at >= 0 {
+		l := &lines[at]
+		l.text = l.text[:l.valueStart] + written + l.text[l.valueEnd:]
+		l.valueEnd = l.valueStart + len(written)
+		c.lines = lines
+		return nil
+	}
+
+	section, key := c.splitItemName(itemName)
+	line := configLine{text: key + "=" + written, section: section, key: key, valueStart: len(key) + 1}
+	line.valueEnd = len(line.text)
+	at = insertPosition(lines, section)
+	lines = append(lines, configLine{})
+	copy(lines[at+1:], lines[at:])
+	lines[at] = line
+	c.lines = lines
+	return nil
+}
+
+// Delete removes an item, it returns false when the item does not exist
+func (c *ConfigReader) Delete(itemName string) bool {
+	if c.readOnly {
+		c.logf("goutils.ConfigReader.Delete(%s) failed: %s", itemName, errReadOnly)
+		return false
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if _, found := c.items[itemName]; !found {
+		return false
+	}
+	items := make(map[string]string, len(c.items))
+	for k, v := range c.items {
+		if k != itemName {
+			items[k] = v
+		}
+	}
+	c.items = items
+	c.forget(itemName)
+
+	var lines []configLine
+	for _, l := range c.lines {
+		if !l.sets(itemName, "") && (c.activeProfile == "" || !l.sets(itemName, c.activeProfile)) {
+			lines = append(lines, l)
+		}
+	}
+	c.lines = lines
+	return true
+}
+
+// Save writes the items back to the file they were read from
+func (c *ConfigReader) Save() error {
+	c.lock.RLock()
+	configPath := c.savePath
+	c.lock.RUnlock()
+	if configPath == "" {
+		return errors.New("goutils.ConfigReader.Save: no config file read from the file system, use SaveAs")
+	}
+	return c.SaveAs(configPath)
+}
+
+// SaveAs writes the items to a file, replacing it atomically.
+// It fails after ReadDotEnv, that keeps no lines to write back
+func (c *ConfigReader) SaveAs(configPath string) error {
+	c.lock.RLock()
+	if c.noLines {
+		c.lock.RUnlock()
+		return fmt.Errorf("goutils.ConfigReader.SaveAs(%s): the items read from .env files cannot be written back, use ToEnv", configPath)
+	}
+	var b strings.Builder
+	for _, l := range c.lines {
+		b.WriteString(l.text)
+		b.WriteString("\n")
+	}
+	format := c.format
+	c.lock.RUnlock()
+
+	data, err := encodeConfig(b.String(), format)
+	if err != nil {
+		return fmt.Errorf("goutils.ConfigReader.SaveAs(%s): %v", configPath, err)
+	}
+	if err := writeFileAtomic(configPath, data); err != nil {
+		return fmt.Errorf("goutils.ConfigReader.SaveAs(%s): %v", configPath, err)
+	}
+
+	// a file saved over one read is not seen as changed by Watch
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	files := append([]watchedFile(nil), c.files...)
+	for i := range files {
+		if files[i].path == configPath && files[i].fsys == nil {
+			files[i].info, _ = os.Stat(configPath)
+		}
+	}
+	c.files = files
+	return nil
+}
+
+// sets tells whether the line sets itemName, for profile or a shared value when profile is empty
+func (l configLine) sets(itemName, profile string) bool {
+	if l.key == "" {
+		return false
+	}
+	name := sectionKey(l.section, l.key)
+	if profile == "" {
+		return name == itemName
+	}
+	base, p, ok := splitProfile(name, profile, nil)
+	return ok && p == profile && base == itemName
+}
+
+// lastLineSetting returns the last of lines setting itemName for profile, -1 when none
+func lastLineSetting(lines []configLine, itemName, profile string) int {
+	for i := len(lines) - 1; i >= 0; i-- {
+		if lines[i].sets(itemName, profile) {
+			return i
+		}
+	}
+	return -1
+}
+
+// splitItemName returns the section an item name belongs to and the key inside it,
+// the longest section matching the name prefix wins
+func (c *ConfigReader) splitItemName(itemName string) (section, key string) {
+	return splitSectionKey(c.sections, itemName)
+}
+
+// splitSectionKey returns the section of sections an item name belongs to and the key inside it
+func splitSectionKey(sections []string, itemName string) (section, key string) {
+	key = itemName
+	for _, s := range sections {
+		if len(s) > len(section) && strings.HasPrefix(itemName, s+".") {
+			section, key = s, itemName[len(s)+1:]
+		}
+	}
+	return
+}
+
+// insertPosition returns where a new key of a section is inserted:
+// after the last key of the section, or after its header when it has no keys
+func insertPosition(lines []configLine, section string) int {
+	at := -1
+	for i, l := range lines {
+		if l.section != section {
+			continue
+		}
+		if l.key != "" {
+			at = i + 1
+		} else if _, header := parseSectionHeader(l.text); header && at < 0 {
+			at = i + 1
+		}
+	}
+	if at >= 0 {
+		return at
+	}
+	if section == "" {
+		// no keys outside sections: before the first section header
+		for i, l := range lines {
+			if l.section != "" {
+				return i
+			}
+		}
+	}
+	return len(lines)
+}
+
+// writeFileAtomic writes data to a temporary file in the same folder and renames it over fullName
+func writeFileAtomic(fullName string, data []byte) (err error) {
+	mode := os.FileMode(0644)
+	if st, errs := os.Stat(fullName); errs == nil {
+		mode = st.Mode().Perm()
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(fullName), filepath.Base(fullName)+".tmp*")
+	if err != nil {
+		return
+	}
+	defer func() {
+		if err != nil {
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	if _, err = tmp.Write(data); err != nil {
+		tmp.Close()
+		return
+	}
+	if err = tmp.Sync(); err != nil {
+		tmp.Close()
+		return
+	}
+	if err = tmp.Close(); err != nil {
+		return
+	}
+	if err = os.Chmod(tmp.Name(), mode); err != nil {
+		return
+	}
+	return os.Rename(tmp.Name(), fullName)
+}
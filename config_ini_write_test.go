@@ -0,0 +1,56 @@
+package goutils
+
+import (
+	"os"
+	"testing"
+)
+
+func TestSetKeepsLines(t *testing.T) {
+	t.Setenv(DefaultProfileEnv, "")
+	paths := writeConfigFiles(t, "app.ini", "# comment\nname=a\n\n[db]\nhost=h ; note\nport=1\n")
+	c := NewConfigReader()
+	c.Read(paths[0])
+	if err := c.Set("db.port", "2"); err != nil {
+		t.Fatal(err)
+	}
+	c.Set("db.user", "u")
+	c.Set("level", "3")
+	c.Delete("name")
+	if err := c.Save(); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(paths[0])
+	if got, want := string(data), "# comment\nlevel=3\n\n[db]\nhost=h ; note\nport=2\nuser=u\n"; got != want {
+		t.Errorf("saved %q, want %q", got, want)
+	}
+}
+
+func TestSetLineEnds(t *testing.T) {
+	t.Setenv(DefaultProfileEnv, "")
+	paths := writeConfigFiles(t, "app.ini", "a=1\n")
+	c := NewConfigReader()
+	c.Read(paths[0])
+	for _, v := range []string{"x\nadmin=true", "x\radmin=true"} {
+		if err := c.Set("a", v); err == nil {
+			t.Errorf("Set(%q) accepted in the legacy syntax", v)
+		}
+	}
+	if got, _ := c.GetString("a"); got != "1" {
+		t.Errorf("a = %q after a refused Set", got)
+	}
+
+	c = NewConfigReader(WithParserMode(ParserModeStandard))
+	c.Read(paths[0])
+	if err := c.Set("a", "x\nadmin=true"); err != nil {
+		t.Fatal(err)
+	}
+	c.Save()
+	r := NewConfigReader(WithParserMode(ParserModeStandard))
+	r.Read(paths[0])
+	if got, _ := r.GetString("a"); got != "x\nadmin=true" {
+		t.Errorf("a = %q after reading again", got)
+	}
+	if r.HasKey("admin") {
+		t.Error("Set added the key admin")
+	}
+}